@@ -13,6 +13,27 @@ var AutoMaintainRange = []any{
 	&models.Post{},
 	&models.Reaction{},
 	&models.Subscription{},
+	&models.LinkPreview{},
+	&models.PollVote{},
+	&models.PostTranslation{},
+	&models.LanguagePreference{},
+	&models.MuteRule{},
+	&models.AccountMute{},
+	&models.AudienceList{},
+	&models.AudienceListMember{},
+	&models.ActorKey{},
+	&models.RemoteActor{},
+	&models.RemoteFollower{},
+	&models.Webhook{},
+	&models.WebhookDelivery{},
+	&models.ReadMarker{},
+	&models.NotificationOutbox{},
+}
+
+// autoMigrateOnly are the models without soft deletion, they are migrated
+// but left out of the cleanup of AutoMaintainRange.
+var autoMigrateOnly = []any{
+	&models.PostDailyView{},
 }
 
 func RunMigration(source *gorm.DB) error {
@@ -21,6 +42,11 @@ func RunMigration(source *gorm.DB) error {
 	); err != nil {
 		return err
 	}
+	if err := source.AutoMigrate(
+		autoMigrateOnly...,
+	); err != nil {
+		return err
+	}
 
 	return nil
 }
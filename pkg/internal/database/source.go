@@ -1,6 +1,8 @@
 package database
 
 import (
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/metrics"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/tracing"
 	"github.com/rs/zerolog/log"
 	"github.com/samber/lo"
 	"github.com/spf13/viper"
@@ -23,6 +25,18 @@ func NewSource() error {
 		IgnoreRecordNotFoundError: true,
 		LogLevel:                  lo.Ternary(viper.GetBool("debug.database"), logger.Info, logger.Silent),
 	})})
+	if err != nil {
+		return err
+	}
+
+	if viper.GetBool("metrics.enabled") {
+		if err = C.Use(metrics.GormPlugin{}); err != nil {
+			return err
+		}
+	}
+	if tracing.IsEnabled() {
+		err = C.Use(tracing.GormPlugin{})
+	}
 
 	return err
 }
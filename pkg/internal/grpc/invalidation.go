@@ -0,0 +1,85 @@
+package grpc
+
+import (
+	"context"
+	"strconv"
+
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/services"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// CacheInvalidationServer lets the other services drop the data this service cached from them.
+// The auth provider calls it after the friends or the blocklist of an account changed,
+// or after a realm was updated, so the change shows up before the cache expired.
+type CacheInvalidationServer interface {
+	InvalidateAccountRelationships(context.Context, *wrapperspb.UInt64Value) (*emptypb.Empty, error)
+	InvalidateRealm(context.Context, *wrapperspb.StringValue) (*emptypb.Empty, error)
+}
+
+// CacheInvalidationServiceDesc is written by hand because the requests are well-known types,
+// the clients call it with grpc.ClientConn.Invoke and the full method name.
+var CacheInvalidationServiceDesc = grpc.ServiceDesc{
+	ServiceName: "interactive.CacheInvalidation",
+	HandlerType: (*CacheInvalidationServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "InvalidateAccountRelationships",
+			Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+				in := new(wrapperspb.UInt64Value)
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				call := func(ctx context.Context, req any) (any, error) {
+					return srv.(CacheInvalidationServer).InvalidateAccountRelationships(ctx, req.(*wrapperspb.UInt64Value))
+				}
+				if interceptor == nil {
+					return call(ctx, in)
+				}
+				info := &grpc.UnaryServerInfo{
+					Server:     srv,
+					FullMethod: "/interactive.CacheInvalidation/InvalidateAccountRelationships",
+				}
+				return interceptor(ctx, in, info, call)
+			},
+		},
+		{
+			MethodName: "InvalidateRealm",
+			Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+				in := new(wrapperspb.StringValue)
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				call := func(ctx context.Context, req any) (any, error) {
+					return srv.(CacheInvalidationServer).InvalidateRealm(ctx, req.(*wrapperspb.StringValue))
+				}
+				if interceptor == nil {
+					return call(ctx, in)
+				}
+				info := &grpc.UnaryServerInfo{
+					Server:     srv,
+					FullMethod: "/interactive.CacheInvalidation/InvalidateRealm",
+				}
+				return interceptor(ctx, in, info, call)
+			},
+		},
+	},
+	Streams: []grpc.StreamDesc{},
+}
+
+func (v *Server) InvalidateAccountRelationships(ctx context.Context, request *wrapperspb.UInt64Value) (*emptypb.Empty, error) {
+	services.InvalidateAccountRelationships(uint(request.GetValue()))
+	return &emptypb.Empty{}, nil
+}
+
+// InvalidateRealm accepts both the id and the alias of the realm,
+// the realm will be fetched again on next lookup.
+func (v *Server) InvalidateRealm(ctx context.Context, request *wrapperspb.StringValue) (*emptypb.Empty, error) {
+	if numericId, err := strconv.Atoi(request.GetValue()); err == nil {
+		services.InvalidateRealm(uint(numericId))
+	} else {
+		services.InvalidateRealmWithAlias(request.GetValue())
+	}
+	return &emptypb.Empty{}, nil
+}
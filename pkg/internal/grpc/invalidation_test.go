@@ -0,0 +1,65 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type recordingInvalidationServer struct {
+	accounts []uint64
+	realms   []string
+}
+
+func (v *recordingInvalidationServer) InvalidateAccountRelationships(ctx context.Context, request *wrapperspb.UInt64Value) (*emptypb.Empty, error) {
+	v.accounts = append(v.accounts, request.GetValue())
+	return &emptypb.Empty{}, nil
+}
+
+func (v *recordingInvalidationServer) InvalidateRealm(ctx context.Context, request *wrapperspb.StringValue) (*emptypb.Empty, error) {
+	v.realms = append(v.realms, request.GetValue())
+	return &emptypb.Empty{}, nil
+}
+
+func TestCacheInvalidationService(t *testing.T) {
+	listener := bufconn.Listen(1024 * 1024)
+	recorder := &recordingInvalidationServer{}
+
+	server := grpc.NewServer()
+	server.RegisterService(&CacheInvalidationServiceDesc, recorder)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx := context.Background()
+	if err := conn.Invoke(ctx, "/interactive.CacheInvalidation/InvalidateAccountRelationships", wrapperspb.UInt64(42), &emptypb.Empty{}); err != nil {
+		t.Fatalf("unable to invalidate account relationships: %v", err)
+	}
+	if err := conn.Invoke(ctx, "/interactive.CacheInvalidation/InvalidateRealm", wrapperspb.String("solar"), &emptypb.Empty{}); err != nil {
+		t.Fatalf("unable to invalidate realm: %v", err)
+	}
+
+	if len(recorder.accounts) != 1 || recorder.accounts[0] != 42 {
+		t.Errorf("expected account 42 to be invalidated, got %v", recorder.accounts)
+	}
+	if len(recorder.realms) != 1 || recorder.realms[0] != "solar" {
+		t.Errorf("expected realm solar to be invalidated, got %v", recorder.realms)
+	}
+}
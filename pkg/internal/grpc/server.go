@@ -20,6 +20,7 @@ func NewGRPC() {
 
 	health.RegisterHealthServer(S, &Server{})
 	proto.RegisterServiceDirectoryServer(S, &Server{})
+	S.RegisterService(&CacheInvalidationServiceDesc, &Server{})
 
 	reflection.Register(S)
 }
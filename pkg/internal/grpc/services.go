@@ -5,6 +5,7 @@ import (
 	"git.solsynth.dev/hydrogen/dealer/pkg/proto"
 	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
 	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/services"
 	"strconv"
 )
 
@@ -24,6 +25,7 @@ func (v *Server) BroadcastDeletion(ctx context.Context, request *proto.DeletionR
 			}
 		}
 		database.C.Delete(&models.Account{}, "id = ?", numericId)
+		services.InvalidateAccountRelationships(uint(numericId))
 	}
 
 	return &proto.DeletionResponse{}, nil
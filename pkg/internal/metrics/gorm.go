@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const gormStartedAtKey = "metrics:started_at"
+
+// GormPlugin times every query gorm runs.
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string {
+	return "metrics"
+}
+
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	before := func(tx *gorm.DB) {
+		tx.InstanceSet(gormStartedAtKey, time.Now())
+	}
+	after := func(operation string) func(*gorm.DB) {
+		return func(tx *gorm.DB) {
+			val, ok := tx.InstanceGet(gormStartedAtKey)
+			if !ok {
+				return
+			}
+			status := "ok"
+			if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+				status = "error"
+			}
+			dbDuration.
+				WithLabelValues(operation, tx.Statement.Table, status).
+				Observe(time.Since(val.(time.Time)).Seconds())
+		}
+	}
+
+	callbacks := db.Callback()
+	if err := callbacks.Create().Before("*").Register("metrics:before_create", before); err != nil {
+		return err
+	} else if err := callbacks.Create().After("*").Register("metrics:after_create", after("create")); err != nil {
+		return err
+	}
+	if err := callbacks.Query().Before("*").Register("metrics:before_query", before); err != nil {
+		return err
+	} else if err := callbacks.Query().After("*").Register("metrics:after_query", after("query")); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("*").Register("metrics:before_update", before); err != nil {
+		return err
+	} else if err := callbacks.Update().After("*").Register("metrics:after_update", after("update")); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("*").Register("metrics:before_delete", before); err != nil {
+		return err
+	} else if err := callbacks.Delete().After("*").Register("metrics:after_delete", after("delete")); err != nil {
+		return err
+	}
+	if err := callbacks.Row().Before("*").Register("metrics:before_row", before); err != nil {
+		return err
+	} else if err := callbacks.Row().After("*").Register("metrics:after_row", after("row")); err != nil {
+		return err
+	}
+	if err := callbacks.Raw().Before("*").Register("metrics:before_raw", before); err != nil {
+		return err
+	} else if err := callbacks.Raw().After("*").Register("metrics:after_raw", after("raw")); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// HttpMiddleware records the requests by the route they matched instead of the path,
+// so the path params won't blow up the label values.
+func HttpMiddleware(c *fiber.Ctx) error {
+	start := time.Now()
+	err := c.Next()
+
+	code := c.Response().StatusCode()
+	if err != nil {
+		var fe *fiber.Error
+		if errors.As(err, &fe) {
+			code = fe.Code
+		} else {
+			code = fiber.StatusInternalServerError
+		}
+	}
+
+	route := c.Route().Path
+	httpRequests.WithLabelValues(c.Method(), route, strconv.Itoa(code)).Inc()
+	httpDuration.WithLabelValues(c.Method(), route).Observe(time.Since(start).Seconds())
+
+	return err
+}
+
+func HttpHandler() fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.Handler())
+}
+
+// TokenMiddleware requires the bearer token when it is not empty.
+func TokenMiddleware(token string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if len(token) == 0 {
+			return c.Next()
+		}
+		provided := []byte(strings.TrimPrefix(c.Get(fiber.HeaderAuthorization), "Bearer "))
+		if subtle.ConstantTimeCompare(provided, []byte(token)) != 1 {
+			return fiber.NewError(fiber.StatusUnauthorized, "invalid metrics token")
+		}
+		return c.Next()
+	}
+}
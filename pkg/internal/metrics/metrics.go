@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc/status"
+)
+
+const namespace = "interactive"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Count of the http requests by route and status.",
+	}, []string{"method", "route", "status"})
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of the http requests by route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	grpcDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "grpc_client_duration_seconds",
+		Help:      "Latency of the grpc calls to the other services.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"service", "method", "code"})
+
+	dbDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Latency of the database queries by operation and table.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table", "status"})
+
+	notificationFanout = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "notification_fanout_recipients",
+		Help:      "Count of the recipients of each notification sent.",
+		Buckets:   prometheus.ExponentialBuckets(1, 4, 8),
+	}, []string{"topic"})
+
+	cronRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cron_runs_total",
+		Help:      "Count of the timed task runs by outcome.",
+	}, []string{"job", "outcome"})
+	cronDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "cron_duration_seconds",
+		Help:      "Duration of the timed task runs.",
+		Buckets:   prometheus.ExponentialBuckets(.01, 4, 8),
+	}, []string{"job"})
+	cronAffected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cron_affected_rows_total",
+		Help:      "Count of the rows affected by the timed tasks.",
+	}, []string{"job"})
+	cronLastSuccess = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "cron_last_success_timestamp_seconds",
+		Help:      "Unix time of the last successful run of the timed tasks.",
+	}, []string{"job"})
+)
+
+// ObserveGrpcCall starts timing a grpc call, call the returned function with the result of the call.
+func ObserveGrpcCall(service, method string) func(error) {
+	start := time.Now()
+	return func(err error) {
+		grpcDuration.
+			WithLabelValues(service, method, status.Code(err).String()).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
+func ObserveNotificationFanout(topic string, recipients int) {
+	notificationFanout.WithLabelValues(topic).Observe(float64(recipients))
+}
+
+func ObserveCronJob(job string, start time.Time, affected int64, err error) {
+	cronDuration.WithLabelValues(job).Observe(time.Since(start).Seconds())
+	cronAffected.WithLabelValues(job).Add(float64(affected))
+	if err != nil {
+		cronRuns.WithLabelValues(job, "failure").Inc()
+	} else {
+		cronRuns.WithLabelValues(job, "success").Inc()
+		cronLastSuccess.WithLabelValues(job).SetToCurrentTime()
+	}
+}
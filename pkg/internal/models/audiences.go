@@ -0,0 +1,23 @@
+package models
+
+import "git.solsynth.dev/hydrogen/dealer/pkg/hyper"
+
+// AudienceList is a named group of accounts, such as close friends, posts with
+// the selected visibility can target it. The members are resolved when reading,
+// so the members added later can see the posts published before.
+type AudienceList struct {
+	hyper.BaseModel
+
+	Name      string               `json:"name"`
+	Members   []AudienceListMember `json:"members,omitempty" gorm:"foreignKey:ListID"`
+	Posts     []Post               `json:"-" gorm:"many2many:post_audience_lists"`
+	AccountID uint                 `json:"account_id"`
+}
+
+type AudienceListMember struct {
+	hyper.BaseModel
+
+	ListID    uint    `json:"list_id" gorm:"uniqueIndex:idx_audience_list_member"`
+	AccountID uint    `json:"account_id" gorm:"uniqueIndex:idx_audience_list_member"`
+	Account   Account `json:"account"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"git.solsynth.dev/hydrogen/dealer/pkg/hyper"
+)
+
+// ActorKey is the key pair of a local actor, used to sign the activities sent to remote servers.
+type ActorKey struct {
+	hyper.BaseModel
+
+	ActorIRI   string `json:"actor_iri" gorm:"uniqueIndex"`
+	PublicKey  string `json:"public_key"`
+	PrivateKey string `json:"-"`
+}
+
+// RemoteActor is an actor on another ActivityPub server, it is mapped into a
+// local account to author replies and reactions.
+type RemoteActor struct {
+	hyper.BaseModel
+
+	IRI               string    `json:"iri" gorm:"uniqueIndex"`
+	Inbox             string    `json:"inbox"`
+	SharedInbox       *string   `json:"shared_inbox"`
+	PreferredUsername string    `json:"preferred_username"`
+	Domain            string    `json:"domain"`
+	PublicKeyID       string    `json:"public_key_id" gorm:"index"`
+	PublicKey         string    `json:"-"`
+	FetchedAt         time.Time `json:"fetched_at"`
+	AccountID         uint      `json:"account_id"`
+	Account           Account   `json:"account"`
+}
+
+// RemoteFollower is a remote actor following a local account or realm.
+type RemoteFollower struct {
+	hyper.BaseModel
+
+	ActorID   uint        `json:"actor_id" gorm:"uniqueIndex:idx_remote_follower"`
+	Actor     RemoteActor `json:"actor"`
+	TargetIRI string      `json:"target_iri" gorm:"uniqueIndex:idx_remote_follower"`
+}
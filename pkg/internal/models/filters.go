@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"git.solsynth.dev/hydrogen/dealer/pkg/hyper"
+)
+
+const (
+	MuteRuleWord     = "word"
+	MuteRuleRegex    = "regex"
+	MuteRuleTag      = "tag"
+	MuteRuleCategory = "category"
+	MuteRuleRealm    = "realm"
+)
+
+type MuteRule struct {
+	hyper.BaseModel
+
+	Type      string     `json:"type"`
+	Value     string     `json:"value"`
+	ExpiredAt *time.Time `json:"expired_at"`
+	AccountID uint       `json:"account_id"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"git.solsynth.dev/hydrogen/dealer/pkg/hyper"
+)
+
+type LinkPreview struct {
+	hyper.BaseModel
+
+	URL         string    `json:"url" gorm:"uniqueIndex"`
+	Title       *string   `json:"title"`
+	Description *string   `json:"description"`
+	Image       *string   `json:"image"`
+	SiteName    *string   `json:"site_name"`
+	Type        *string   `json:"type"`
+	FetchedAt   time.Time `json:"fetched_at"`
+	Posts       []Post    `json:"posts" gorm:"many2many:post_link_previews"`
+}
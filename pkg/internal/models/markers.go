@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"git.solsynth.dev/hydrogen/dealer/pkg/hyper"
+)
+
+const (
+	ReadMarkerFeedGlobal       = "global"
+	ReadMarkerFeedRealm        = "realm"
+	ReadMarkerFeedSubscription = "subscription"
+)
+
+// ReadMarker remembers the last post the account read in a feed,
+// the realm feeds are keyed like realm:<id>. The feeds are ordered by the
+// published time, so the marker is the published time and the id of the post.
+type ReadMarker struct {
+	hyper.BaseModel
+
+	Feed       string     `json:"feed" gorm:"uniqueIndex:idx_read_marker"`
+	LastReadID uint       `json:"last_read_id"`
+	LastReadAt *time.Time `json:"last_read_at"`
+	ReadAt     *time.Time `json:"read_at"`
+	AccountID  uint       `json:"account_id" gorm:"uniqueIndex:idx_read_marker"`
+}
@@ -1,7 +1,24 @@
 package models
 
+import "time"
+
 type PostMetric struct {
+	ViewCount     int64            `json:"view_count"`
 	ReplyCount    int64            `json:"reply_count"`
 	ReactionCount int64            `json:"reaction_count"`
 	ReactionList  map[string]int64 `json:"reaction_list,omitempty"`
+	PollVoteCount int64            `json:"poll_vote_count,omitempty"`
+	PollVoteList  map[int]int64    `json:"poll_vote_list,omitempty"`
+	PollChoices   []int            `json:"poll_choices,omitempty"`
+}
+
+// PostDailyView is the views of a post in a day, the date is in UTC.
+type PostDailyView struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Date   time.Time `json:"date" gorm:"type:date;uniqueIndex:idx_post_daily_view"`
+	Count  int64     `json:"count"`
+	PostID uint      `json:"post_id" gorm:"uniqueIndex:idx_post_daily_view"`
 }
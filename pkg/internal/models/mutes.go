@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"git.solsynth.dev/hydrogen/dealer/pkg/hyper"
+)
+
+// AccountMute hides the muted account's posts and notifications from the account,
+// it only exists in Interactive so the muted account never knows about it.
+type AccountMute struct {
+	hyper.BaseModel
+
+	AccountID uint       `json:"account_id" gorm:"uniqueIndex:idx_account_mute"`
+	MutedID   uint       `json:"muted_id" gorm:"uniqueIndex:idx_account_mute"`
+	Muted     Account    `json:"muted"`
+	ExpiredAt *time.Time `json:"expired_at"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"git.solsynth.dev/hydrogen/dealer/pkg/hyper"
+	"gorm.io/datatypes"
+)
+
+const (
+	NotificationKindPoster               = "poster"
+	NotificationKindAccountSubscription  = "subscription.account"
+	NotificationKindTagSubscription      = "subscription.tag"
+	NotificationKindCategorySubscription = "subscription.category"
+	NotificationKindPollClosed           = "poll.closed"
+)
+
+const (
+	NotificationOutboxPending = "pending"
+	NotificationOutboxSent    = "sent"
+	NotificationOutboxDead    = "dead"
+)
+
+// NotificationOutbox is a notification waiting to be sent to the notifier,
+// it is written in the same transaction as the change caused it.
+type NotificationOutbox struct {
+	hyper.BaseModel
+
+	Kind          string         `json:"kind"`
+	Payload       datatypes.JSON `json:"payload"`
+	Status        string         `json:"status" gorm:"index"`
+	Attempts      int            `json:"attempts"`
+	NextAttemptAt *time.Time     `json:"next_attempt_at" gorm:"index"`
+	SentAt        *time.Time     `json:"sent_at"`
+	Error         string         `json:"error"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"git.solsynth.dev/hydrogen/dealer/pkg/hyper"
+)
+
+type PostPollBody struct {
+	Thumbnail     *uint            `json:"thumbnail"`
+	Title         *string          `json:"title"`
+	Content       string           `json:"content" validate:"required,max=4096"`
+	Options       []PostPollOption `json:"options" validate:"required,min=2,max=32,dive"`
+	IsMultiChoice bool             `json:"is_multi_choice"`
+	IsAnonymous   bool             `json:"is_anonymous"`
+	ExpiredAt     *time.Time       `json:"expired_at"`
+	ClosedAt      *time.Time       `json:"closed_at"`
+	Attachments   []string         `json:"attachments"`
+}
+
+type PostPollOption struct {
+	ID    int    `json:"id"`
+	Label string `json:"label" validate:"required,max=256"`
+}
+
+type PollVote struct {
+	hyper.BaseModel
+
+	PostID    uint    `json:"post_id" gorm:"uniqueIndex:idx_poll_vote"`
+	OptionID  int     `json:"option_id" gorm:"uniqueIndex:idx_poll_vote"`
+	AccountID uint    `json:"account_id" gorm:"uniqueIndex:idx_poll_vote"`
+	Account   Account `json:"account"`
+}
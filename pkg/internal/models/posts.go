@@ -10,15 +10,23 @@ import (
 const (
 	PostTypeStory   = "story"
 	PostTypeArticle = "article"
+	PostTypePoll    = "poll"
 )
 
 type PostVisibilityLevel = int8
 
+// The post visibility levels, the author can always see their own posts,
+// and the others never see the posts of the authors they blocked.
 const (
+	// PostVisibilityAll is public to everyone, including the anonymous users
 	PostVisibilityAll = PostVisibilityLevel(iota)
+	// PostVisibilityFriends is only visible to the author's friends
 	PostVisibilityFriends
+	// PostVisibilityFiltered is visible to the signed-in users except the InvisibleUsers
 	PostVisibilityFiltered
+	// PostVisibilitySelected is only visible to the VisibleUsers and the members of the AudienceLists
 	PostVisibilitySelected
+	// PostVisibilityNone is private to the author
 	PostVisibilityNone
 )
 
@@ -32,6 +40,7 @@ type Post struct {
 	AreaAlias  *string           `json:"area_alias"`
 	Tags       []Tag             `json:"tags" gorm:"many2many:post_tags"`
 	Categories []Category        `json:"categories" gorm:"many2many:post_categories"`
+	Links      []LinkPreview     `json:"link_previews" gorm:"many2many:post_link_previews"`
 	Reactions  []Reaction        `json:"reactions"`
 	Replies    []Post            `json:"replies" gorm:"foreignKey:ReplyID"`
 	ReplyID    *uint             `json:"reply_id"`
@@ -41,8 +50,20 @@ type Post struct {
 	RepostTo   *Post             `json:"repost_to" gorm:"foreignKey:RepostID"`
 	Realm      *Realm            `json:"realm"`
 
+	RenderedContent  string `json:"-"`
+	PlaintextContent string `json:"-"`
+	WordCount        int    `json:"word_count"`
+	ReadingTime      int    `json:"reading_time"`
+
+	LanguageConfidence  float64 `json:"language_confidence"`
+	IsLanguageSpecified bool    `json:"is_language_specified"`
+
+	Translations   []PostTranslation `json:"translations,omitempty"`
+	TranslatedFrom *string           `json:"translated_from,omitempty" gorm:"-"`
+
 	VisibleUsers   datatypes.JSONSlice[uint] `json:"visible_users_list"`
 	InvisibleUsers datatypes.JSONSlice[uint] `json:"invisible_users_list"`
+	AudienceLists  []AudienceList            `json:"audience_lists,omitempty" gorm:"many2many:post_audience_lists"`
 	Visibility     PostVisibilityLevel       `json:"visibility"`
 
 	EditedAt *time.Time `json:"edited_at"`
@@ -53,27 +74,40 @@ type Post struct {
 	PublishedAt    *time.Time `json:"published_at"`
 	PublishedUntil *time.Time `json:"published_until"`
 
-	TotalUpvote   int `json:"total_upvote"`
-	TotalDownvote int `json:"total_downvote"`
+	TotalUpvote   int   `json:"total_upvote"`
+	TotalDownvote int   `json:"total_downvote"`
+	TotalViews    int64 `json:"total_views"`
 
 	AuthorID uint    `json:"author_id"`
 	Author   Account `json:"author"`
 
+	// FederatedIRI is the id of the post on its origin server, only set for the posts from remote servers
+	FederatedIRI *string `json:"federated_iri,omitempty" gorm:"uniqueIndex"`
+	// FederatedAt is when the local post was delivered to the remote followers
+	FederatedAt *time.Time `json:"federated_at,omitempty"`
+
 	Metric PostMetric `json:"metric" gorm:"-"`
 }
 
 type PostStoryBody struct {
 	Thumbnail   *uint    `json:"thumbnail"`
 	Title       *string  `json:"title"`
-	Content     string   `json:"content"`
+	Content     string   `json:"content" validate:"required,max=4096"`
 	Location    *string  `json:"location"`
 	Attachments []string `json:"attachments"`
 }
 
 type PostArticleBody struct {
-	Thumbnail   *uint    `json:"thumbnail"`
-	Title       string   `json:"title"`
-	Description *string  `json:"description"`
-	Content     string   `json:"content"`
-	Attachments []string `json:"attachments"`
+	Thumbnail       *uint                `json:"thumbnail"`
+	Title           string               `json:"title" validate:"required,max=1024"`
+	Description     *string              `json:"description"`
+	Content         string               `json:"content" validate:"required"`
+	Attachments     []string             `json:"attachments"`
+	TableOfContents []PostArticleHeading `json:"table_of_contents,omitempty"`
+}
+
+type PostArticleHeading struct {
+	Level  int    `json:"level"`
+	Title  string `json:"title"`
+	Anchor string `json:"anchor"`
 }
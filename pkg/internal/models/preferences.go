@@ -0,0 +1,14 @@
+package models
+
+import (
+	"git.solsynth.dev/hydrogen/dealer/pkg/hyper"
+	"gorm.io/datatypes"
+)
+
+type LanguagePreference struct {
+	hyper.BaseModel
+
+	Preferred datatypes.JSONSlice[string] `json:"preferred"`
+	Excluded  datatypes.JSONSlice[string] `json:"excluded"`
+	AccountID uint                        `json:"account_id" gorm:"uniqueIndex"`
+}
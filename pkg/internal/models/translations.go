@@ -0,0 +1,16 @@
+package models
+
+import (
+	"git.solsynth.dev/hydrogen/dealer/pkg/hyper"
+	"gorm.io/datatypes"
+)
+
+type PostTranslation struct {
+	hyper.BaseModel
+
+	Language     string            `json:"language" gorm:"uniqueIndex:idx_post_translation"`
+	Body         datatypes.JSONMap `json:"body"`
+	PostID       uint              `json:"post_id" gorm:"uniqueIndex:idx_post_translation"`
+	TranslatorID uint              `json:"translator_id"`
+	Translator   Account           `json:"translator"`
+}
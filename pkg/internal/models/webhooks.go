@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"git.solsynth.dev/hydrogen/dealer/pkg/hyper"
+	"gorm.io/datatypes"
+)
+
+const (
+	WebhookEventPostCreated   = "post.created"
+	WebhookEventPostEdited    = "post.edited"
+	WebhookEventPostDeleted   = "post.deleted"
+	WebhookEventPostReplied   = "post.replied"
+	WebhookEventReactionAdded = "reaction.added"
+)
+
+var WebhookEvents = []string{
+	WebhookEventPostCreated,
+	WebhookEventPostEdited,
+	WebhookEventPostDeleted,
+	WebhookEventPostReplied,
+	WebhookEventReactionAdded,
+}
+
+// Webhook receives the events of the posts published by the account,
+// or the posts in the realm when the realm is set.
+type Webhook struct {
+	hyper.BaseModel
+
+	URL       string                      `json:"url"`
+	Secret    string                      `json:"-"`
+	Events    datatypes.JSONSlice[string] `json:"events"`
+	IsActive  bool                        `json:"is_active"`
+	RealmID   *uint                       `json:"realm_id"`
+	Realm     *Realm                      `json:"realm,omitempty"`
+	AccountID uint                        `json:"account_id"`
+}
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+type WebhookDelivery struct {
+	hyper.BaseModel
+
+	Event         string         `json:"event"`
+	Payload       datatypes.JSON `json:"payload"`
+	Status        string         `json:"status" gorm:"index"`
+	Attempts      int            `json:"attempts"`
+	NextAttemptAt *time.Time     `json:"next_attempt_at" gorm:"index"`
+	DeliveredAt   *time.Time     `json:"delivered_at"`
+	ResponseCode  int            `json:"response_code"`
+	ResponseBody  string         `json:"response_body"`
+	Error         string         `json:"error"`
+	RedeliveryOf  *uint          `json:"redelivery_of"`
+	WebhookID     uint           `json:"webhook_id" gorm:"index"`
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"fmt"
+
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/gap"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/server/exts"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+func listAudienceLists(c *fiber.Ctx) error {
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	lists, err := services.ListAudienceLists(user)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(lists)
+}
+
+func getAudienceList(c *fiber.Ctx) error {
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	id, _ := c.ParamsInt("listId", 0)
+	list, err := services.GetAudienceListWithUser(uint(id), user)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	return c.JSON(list)
+}
+
+func newAudienceList(c *fiber.Ctx) error {
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	var data struct {
+		Name string `json:"name" validate:"required,max=64"`
+	}
+
+	if err := exts.BindAndValidate(c, &data); err != nil {
+		return err
+	}
+
+	list, err := services.NewAudienceList(user, data.Name)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(list)
+}
+
+func editAudienceList(c *fiber.Ctx) error {
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	id, _ := c.ParamsInt("listId", 0)
+	list, err := services.GetAudienceListWithUser(uint(id), user)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	var data struct {
+		Name string `json:"name" validate:"required,max=64"`
+	}
+
+	if err := exts.BindAndValidate(c, &data); err != nil {
+		return err
+	}
+
+	list, err = services.EditAudienceList(list, data.Name)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(list)
+}
+
+func deleteAudienceList(c *fiber.Ctx) error {
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	id, _ := c.ParamsInt("listId", 0)
+	list, err := services.GetAudienceListWithUser(uint(id), user)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	if err := services.DeleteAudienceList(list); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(list)
+}
+
+func addAudienceListMember(c *fiber.Ctx) error {
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	id, _ := c.ParamsInt("listId", 0)
+	list, err := services.GetAudienceListWithUser(uint(id), user)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	otherUserId, _ := c.ParamsInt("userId", 0)
+	otherUser, err := services.GetAccountWithID(uint(otherUserId))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("unable to get user: %v", err))
+	}
+
+	member, err := services.AddAudienceListMember(list, otherUser)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(member)
+}
+
+func removeAudienceListMember(c *fiber.Ctx) error {
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	id, _ := c.ParamsInt("listId", 0)
+	list, err := services.GetAudienceListWithUser(uint(id), user)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	otherUserId, _ := c.ParamsInt("userId", 0)
+	otherUser, err := services.GetAccountWithID(uint(otherUserId))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("unable to get user: %v", err))
+	}
+
+	if err := services.RemoveAudienceListMember(list, otherUser); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
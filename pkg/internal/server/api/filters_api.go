@@ -0,0 +1,114 @@
+package api
+
+import (
+	"time"
+
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/gap"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/server/exts"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type muteRulePayload struct {
+	Type      string     `json:"type" validate:"required,oneof=word regex tag category realm"`
+	Value     string     `json:"value" validate:"required,max=256"`
+	ExpiredAt *time.Time `json:"expired_at"`
+}
+
+// mutePostFilter hides the posts matched by the user's active mute rules.
+func mutePostFilter(c *fiber.Ctx, tx *gorm.DB) *gorm.DB {
+	user, authenticated := c.Locals("user").(models.Account)
+	if !authenticated {
+		return tx
+	}
+
+	rules, err := services.ListActiveMuteRules(user)
+	if err != nil || len(rules) == 0 {
+		return tx
+	}
+
+	return services.FilterPostWithMuteRules(tx, rules)
+}
+
+func listMuteRules(c *fiber.Ctx) error {
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	rules, err := services.ListMuteRules(user)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(rules)
+}
+
+func newMuteRule(c *fiber.Ctx) error {
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	var data muteRulePayload
+	if err := exts.BindAndValidate(c, &data); err != nil {
+		return err
+	}
+
+	rule, err := services.NewMuteRule(user, models.MuteRule{
+		Type:      data.Type,
+		Value:     data.Value,
+		ExpiredAt: data.ExpiredAt,
+	})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(rule)
+}
+
+func editMuteRule(c *fiber.Ctx) error {
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	id, _ := c.ParamsInt("filterId", 0)
+	rule, err := services.GetMuteRuleWithUser(uint(id), user)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	var data muteRulePayload
+	if err := exts.BindAndValidate(c, &data); err != nil {
+		return err
+	}
+
+	rule, err = services.EditMuteRule(rule, data.Type, data.Value, data.ExpiredAt)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(rule)
+}
+
+func deleteMuteRule(c *fiber.Ctx) error {
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	id, _ := c.ParamsInt("filterId", 0)
+	rule, err := services.GetMuteRuleWithUser(uint(id), user)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	if err := services.DeleteMuteRule(rule); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(rule)
+}
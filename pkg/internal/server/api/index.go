@@ -1,6 +1,7 @@
 package api
 
 import (
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -8,6 +9,13 @@ func MapAPIs(app *fiber.App, baseURL string) {
 	api := app.Group(baseURL).Name("API")
 	{
 		api.Get("/users/me", getUserinfo)
+		api.Get("/users/me/analytics", getUserAnalytics)
+		api.Get("/users/me/languages", getLanguagePreference)
+		api.Put("/users/me/languages", setLanguagePreference)
+		api.Get("/users/me/filters", listMuteRules)
+		api.Post("/users/me/filters", newMuteRule)
+		api.Put("/users/me/filters/:filterId", editMuteRule)
+		api.Delete("/users/me/filters/:filterId", deleteMuteRule)
 		api.Get("/users/:account", getOthersInfo)
 		api.Get("/users/:account/pin", listOthersPinnedPost)
 
@@ -22,13 +30,20 @@ func MapAPIs(app *fiber.App, baseURL string) {
 
 		stories := api.Group("/stories").Name("Story API")
 		{
-			stories.Post("/", createStory)
-			stories.Put("/:postId", editStory)
+			stories.Post("/", createPostWithType(models.PostTypeStory))
+			stories.Put("/:postId", editPostWithType(models.PostTypeStory))
 		}
 		articles := api.Group("/articles").Name("Article API")
 		{
-			articles.Post("/", createArticle)
-			articles.Put("/:postId", editArticle)
+			articles.Post("/", createPostWithType(models.PostTypeArticle))
+			articles.Put("/:postId", editPostWithType(models.PostTypeArticle))
+		}
+		polls := api.Group("/polls").Name("Poll API")
+		{
+			polls.Post("/", createPostWithType(models.PostTypePoll))
+			polls.Get("/:postId/votes", listPollVotes)
+			polls.Post("/:postId/vote", votePoll)
+			polls.Post("/:postId/close", closePoll)
 		}
 
 		posts := api.Group("/posts").Name("Posts API")
@@ -38,12 +53,18 @@ func MapAPIs(app *fiber.App, baseURL string) {
 			posts.Get("/minimal", listPostMinimal)
 			posts.Get("/drafts", listDraftPost)
 			posts.Get("/:postId", getPost)
+			posts.Post("/", createPost)
+			posts.Put("/:postId", editPost)
 			posts.Post("/:postId/react", reactPost)
 			posts.Post("/:postId/pin", pinPost)
 			posts.Delete("/:postId", deletePost)
 
 			posts.Get("/:postId/replies", listPostReplies)
 			posts.Get("/:postId/replies/featured", listPostFeaturedReply)
+
+			posts.Get("/:postId/translations", listPostTranslations)
+			posts.Put("/:postId/translations/:lang", setPostTranslation)
+			posts.Delete("/:postId/translations/:lang", deletePostTranslation)
 		}
 
 		subscriptions := api.Group("/subscriptions").Name("Subscriptions API")
@@ -62,6 +83,56 @@ func MapAPIs(app *fiber.App, baseURL string) {
 			subscriptions.Delete("/realms/:realmId", unsubscribeFromRealm)
 		}
 
+		audiences := api.Group("/audiences").Name("Audience Lists API")
+		{
+			audiences.Get("/", listAudienceLists)
+			audiences.Get("/:listId", getAudienceList)
+			audiences.Post("/", newAudienceList)
+			audiences.Put("/:listId", editAudienceList)
+			audiences.Delete("/:listId", deleteAudienceList)
+			audiences.Post("/:listId/members/:userId", addAudienceListMember)
+			audiences.Delete("/:listId/members/:userId", removeAudienceListMember)
+		}
+
+		webhooks := api.Group("/webhooks").Name("Webhooks API")
+		{
+			webhooks.Get("/", listWebhooks)
+			webhooks.Get("/:hookId", getWebhook)
+			webhooks.Post("/", newWebhook)
+			webhooks.Put("/:hookId", editWebhook)
+			webhooks.Delete("/:hookId", deleteWebhook)
+			webhooks.Post("/:hookId/secret", rotateWebhookSecret)
+			webhooks.Get("/:hookId/deliveries", listWebhookDeliveries)
+			webhooks.Get("/:hookId/deliveries/:deliveryId", getWebhookDelivery)
+			webhooks.Post("/:hookId/deliveries/:deliveryId/redeliver", redeliverWebhook)
+		}
+
+		realmAnalytics := api.Group("/realms/:realm/analytics").Name("Realm Analytics API")
+		{
+			realmAnalytics.Get("/posts", getRealmDailyPosts)
+			realmAnalytics.Get("/posters", getRealmActivePosters)
+			realmAnalytics.Get("/top-posts", getRealmTopPosts)
+			realmAnalytics.Get("/top-tags", getRealmTopTags)
+			realmAnalytics.Get("/reactions", getRealmReactionDistribution)
+			realmAnalytics.Get("/subscribers", getRealmSubscriberGrowth)
+		}
+
+		outbox := api.Group("/notifications/outbox").Name("Notification Outbox API")
+		{
+			outbox.Get("/", listNotificationOutbox)
+			outbox.Get("/:outboxId", getNotificationOutbox)
+			outbox.Post("/:outboxId/retry", retryNotificationOutbox)
+			outbox.Delete("/:outboxId", deleteNotificationOutbox)
+		}
+
+		mutes := api.Group("/mutes").Name("Mutes API")
+		{
+			mutes.Get("/", listAccountMutes)
+			mutes.Get("/users/:userId", getMuteOnUser)
+			mutes.Post("/users/:userId", muteUser)
+			mutes.Delete("/users/:userId", unmuteUser)
+		}
+
 		api.Get("/categories", listCategories)
 		api.Get("/categories/:category", getCategory)
 		api.Post("/categories", newCategory)
@@ -72,5 +143,8 @@ func MapAPIs(app *fiber.App, baseURL string) {
 		api.Get("/tags/:tag", getTag)
 
 		api.Get("/whats-new", getWhatsNew)
+		api.Get("/whats-new/count", getWhatsNewCount)
+		api.Post("/whats-new/read", markWhatsNewAsRead)
+		api.Get("/streams/posts", streamPostEvents)
 	}
 }
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/gap"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/server/exts"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+func listAccountMutes(c *fiber.Ctx) error {
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	mutes, err := services.ListAccountMutes(user)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(mutes)
+}
+
+func getMuteOnUser(c *fiber.Ctx) error {
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	otherUserId, _ := c.ParamsInt("userId", 0)
+	otherUser, err := services.GetAccountWithID(uint(otherUserId))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("unable to get user: %v", err))
+	}
+
+	mute, err := services.GetAccountMute(user, otherUser)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unable to get mute: %v", err))
+	} else if mute == nil {
+		return fiber.NewError(fiber.StatusNotFound, "mute does not exist")
+	}
+
+	return c.JSON(mute)
+}
+
+func muteUser(c *fiber.Ctx) error {
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	var data struct {
+		ExpiredAt *time.Time `json:"expired_at"`
+	}
+
+	if len(c.Body()) > 0 {
+		if err := exts.BindAndValidate(c, &data); err != nil {
+			return err
+		}
+	}
+
+	otherUserId, _ := c.ParamsInt("userId", 0)
+	otherUser, err := services.GetAccountWithID(uint(otherUserId))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("unable to get user: %v", err))
+	}
+
+	mute, err := services.MuteAccount(user, otherUser, data.ExpiredAt)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unable to mute user: %v", err))
+	}
+
+	return c.JSON(mute)
+}
+
+func unmuteUser(c *fiber.Ctx) error {
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	otherUserId, _ := c.ParamsInt("userId", 0)
+	otherUser, err := services.GetAccountWithID(uint(otherUserId))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("unable to get user: %v", err))
+	}
+
+	if err := services.UnmuteAccount(user, otherUser); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unable to unmute user: %v", err))
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/gap"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/samber/lo"
+)
+
+func listNotificationOutbox(c *fiber.Ctx) error {
+	take := c.QueryInt("take", 0)
+	offset := c.QueryInt("offset", 0)
+	status := c.Query("status", models.NotificationOutboxDead)
+
+	if err := gap.H.EnsureGrantedPerm(c, "ManageNotificationOutbox", true); err != nil {
+		return err
+	}
+
+	if len(status) > 0 && !lo.Contains([]string{
+		models.NotificationOutboxPending,
+		models.NotificationOutboxSent,
+		models.NotificationOutboxDead,
+	}, status) {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unknown status %s", status))
+	}
+
+	count, err := services.CountNotificationOutbox(status)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	items, err := services.ListNotificationOutbox(status, take, offset)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"count": count,
+		"data":  items,
+	})
+}
+
+func getNotificationOutbox(c *fiber.Ctx) error {
+	id, _ := c.ParamsInt("outboxId", 0)
+
+	if err := gap.H.EnsureGrantedPerm(c, "ManageNotificationOutbox", true); err != nil {
+		return err
+	}
+
+	item, err := services.GetNotificationOutbox(uint(id))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	return c.JSON(item)
+}
+
+func retryNotificationOutbox(c *fiber.Ctx) error {
+	id, _ := c.ParamsInt("outboxId", 0)
+
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	if err := gap.H.EnsureGrantedPerm(c, "ManageNotificationOutbox", true); err != nil {
+		return err
+	}
+
+	item, err := services.GetNotificationOutbox(uint(id))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	item, err = services.RetryNotificationOutbox(item)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	} else {
+		_ = gap.H.RecordAuditLog(
+			user.ID,
+			"notifications.outbox.retry",
+			strconv.Itoa(int(item.ID)),
+			c.IP(),
+			c.Get(fiber.HeaderUserAgent),
+		)
+	}
+
+	return c.JSON(item)
+}
+
+func deleteNotificationOutbox(c *fiber.Ctx) error {
+	id, _ := c.ParamsInt("outboxId", 0)
+
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	if err := gap.H.EnsureGrantedPerm(c, "ManageNotificationOutbox", true); err != nil {
+		return err
+	}
+
+	item, err := services.GetNotificationOutbox(uint(id))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	if err := services.DeleteNotificationOutbox(item); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	} else {
+		_ = gap.H.RecordAuditLog(
+			user.ID,
+			"notifications.outbox.delete",
+			strconv.Itoa(int(item.ID)),
+			c.IP(),
+			c.Get(fiber.HeaderUserAgent),
+		)
+	}
+
+	return c.JSON(item)
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/gap"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/server/exts"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+func getPollWithUserContext(c *fiber.Ctx, user models.Account) (models.Post, error) {
+	id, _ := c.ParamsInt("postId", 0)
+
+	tx := services.FilterPostReadable(database.C, &user)
+	tx = tx.Where("type = ?", models.PostTypePoll)
+
+	item, err := services.GetPost(tx, uint(id))
+	if err != nil {
+		return item, fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("unable to find poll: %v", err))
+	}
+	return item, nil
+}
+
+func votePoll(c *fiber.Ctx) error {
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	var data struct {
+		Options []int `json:"options" validate:"required,min=1"`
+	}
+
+	if err := exts.BindAndValidate(c, &data); err != nil {
+		return err
+	}
+
+	item, err := getPollWithUserContext(c, user)
+	if err != nil {
+		return err
+	}
+
+	votes, err := services.VotePoll(user, item, data.Options)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	} else {
+		_ = gap.H.RecordAuditLog(
+			user.ID,
+			"posts.polls.vote",
+			strconv.Itoa(int(item.ID)),
+			c.IP(),
+			c.Get(fiber.HeaderUserAgent),
+		)
+	}
+
+	return c.JSON(votes)
+}
+
+func closePoll(c *fiber.Ctx) error {
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	item, err := getPollWithUserContext(c, user)
+	if err != nil {
+		return err
+	} else if item.AuthorID != user.ID {
+		return fiber.NewError(fiber.StatusForbidden, "only the author can close the poll")
+	}
+
+	if item, err = services.ClosePoll(item); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	} else {
+		_ = gap.H.RecordAuditLog(
+			user.ID,
+			"posts.polls.close",
+			strconv.Itoa(int(item.ID)),
+			c.IP(),
+			c.Get(fiber.HeaderUserAgent),
+		)
+	}
+
+	return c.JSON(item)
+}
+
+func listPollVotes(c *fiber.Ctx) error {
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	item, err := getPollWithUserContext(c, user)
+	if err != nil {
+		return err
+	}
+
+	body, err := services.DecodePollBody(item)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	} else if body.IsAnonymous {
+		return fiber.NewError(fiber.StatusForbidden, "voters of an anonymous poll are not public")
+	}
+
+	if err := services.LoadPollMetrics([]*models.Post{&item}, &user); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	} else if item.Metric.PollVoteList == nil {
+		return fiber.NewError(fiber.StatusForbidden, "vote the poll to see the results")
+	}
+
+	votes, err := services.ListPollVotes(item)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(votes)
+}
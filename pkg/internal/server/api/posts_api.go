@@ -14,21 +14,20 @@ import (
 	"git.solsynth.dev/hydrogen/interactive/pkg/internal/services"
 	"github.com/gofiber/fiber/v2"
 	"github.com/samber/lo"
+	"golang.org/x/text/language"
 )
 
 func universalPostFilter(c *fiber.Ctx, tx *gorm.DB) (*gorm.DB, error) {
 	realm := c.Query("realm")
 
-	tx = services.FilterPostDraft(tx)
-
 	if user, authenticated := c.Locals("user").(models.Account); authenticated {
-		tx = services.FilterPostWithUserContext(tx, &user)
+		tx = services.FilterPostReadable(tx, &user)
 	} else {
-		tx = services.FilterPostWithUserContext(tx, nil)
+		tx = services.FilterPostReadable(tx, nil)
 	}
 
 	if len(realm) > 0 {
-		if realm, err := services.GetRealmWithAlias(realm); err != nil {
+		if realm, err := services.GetRealmWithAlias(c.UserContext(), realm); err != nil {
 			return tx, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("realm was not found: %v", err))
 		} else {
 			tx = services.FilterPostWithRealm(tx, realm.ID)
@@ -54,21 +53,112 @@ func universalPostFilter(c *fiber.Ctx, tx *gorm.DB) (*gorm.DB, error) {
 		tx = services.FilterPostWithTag(tx, c.Query("tag"))
 	}
 
+	tx = languagePostFilter(c, tx)
+	tx = mutePostFilter(c, tx)
+
+	if val := c.QueryInt("minWords", 0); val > 0 {
+		tx = tx.Where("word_count >= ?", val)
+	}
+	if val := c.QueryInt("maxWords", 0); val > 0 {
+		tx = tx.Where("word_count <= ?", val)
+	}
+	if val := c.QueryInt("minReadingTime", 0); val > 0 {
+		tx = tx.Where("reading_time >= ?", val)
+	}
+	if val := c.QueryInt("maxReadingTime", 0); val > 0 {
+		tx = tx.Where("reading_time <= ?", val)
+	}
+
 	return tx, nil
 }
 
+var postSortableFields = map[string]string{
+	"published":   "published_at",
+	"words":       "word_count",
+	"readingTime": "reading_time",
+}
+
+func universalPostOrder(c *fiber.Ctx) (string, error) {
+	field, ok := postSortableFields[c.Query("sort", "published")]
+	if !ok {
+		return "", fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unable to sort posts by %s", c.Query("sort")))
+	}
+
+	if c.QueryBool("ascending", false) {
+		return field + " ASC", nil
+	}
+	return field + " DESC", nil
+}
+
+// languagePostFilter applies the languages and excludeLanguages query,
+// the user's language preference is used when the query is absent.
+func languagePostFilter(c *fiber.Ctx, tx *gorm.DB) *gorm.DB {
+	parse := func(raw string) []string {
+		languages := lo.Map(strings.Split(raw, ","), func(item string, index int) string {
+			return services.NormalizeLanguage(item)
+		})
+		return lo.Uniq(lo.Compact(languages))
+	}
+
+	var languages, excluded []string
+	if user, authenticated := c.Locals("user").(models.Account); authenticated {
+		if pref, err := services.GetLanguagePreference(user); err == nil {
+			languages, excluded = pref.Preferred, pref.Excluded
+		}
+	}
+	if len(c.Query("languages")) > 0 {
+		languages = parse(c.Query("languages"))
+	}
+	if len(c.Query("excludeLanguages")) > 0 {
+		excluded = parse(c.Query("excludeLanguages"))
+	}
+
+	if len(languages) > 0 {
+		tx = services.FilterPostWithLanguages(tx, languages)
+	}
+	if len(excluded) > 0 {
+		tx = services.FilterPostWithoutLanguages(tx, excluded)
+	}
+
+	return tx
+}
+
+func negotiatePostLanguages(c *fiber.Ctx) []string {
+	var languages []string
+	if len(c.Query("lang")) > 0 {
+		languages = strings.Split(c.Query("lang"), ",")
+	} else if tags, _, err := language.ParseAcceptLanguage(c.Get(fiber.HeaderAcceptLanguage)); err == nil {
+		languages = lo.Map(tags, func(item language.Tag, index int) string {
+			base, _ := item.Base()
+			return base.String()
+		})
+	}
+
+	languages = lo.Map(languages, func(item string, index int) string {
+		return services.NormalizeLanguage(item)
+	})
+	return lo.Uniq(lo.Compact(languages))
+}
+
+func loadPollMetrics(c *fiber.Ctx, items []*models.Post) error {
+	if user, authenticated := c.Locals("user").(models.Account); authenticated {
+		return services.LoadPollMetrics(items, &user)
+	}
+	return services.LoadPollMetrics(items, nil)
+}
+
 func getPost(c *fiber.Ctx) error {
 	id := c.Params("postId")
 
 	var item models.Post
 	var err error
 
-	tx := services.FilterPostDraft(database.C)
+	tx := database.C.WithContext(c.UserContext())
 
 	if user, authenticated := c.Locals("user").(models.Account); authenticated {
-		tx = services.FilterPostWithUserContext(tx, &user)
+		tx = services.FilterPostReadable(tx, &user)
 	} else {
-		tx = services.FilterPostWithUserContext(tx, nil)
+		tx = services.FilterPostReadable(tx, nil)
 	}
 
 	if numericId, paramErr := strconv.Atoi(id); paramErr == nil {
@@ -87,8 +177,19 @@ func getPost(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusNotFound, err.Error())
 	}
 
+	var viewer *models.Account
+	if user, authenticated := c.Locals("user").(models.Account); !authenticated {
+		services.RecordPostView(item, "ip:"+c.IP())
+	} else {
+		viewer = &user
+		if user.ID != item.AuthorID {
+			services.RecordPostView(item, "account:"+strconv.Itoa(int(user.ID)))
+		}
+	}
+
 	item.Metric = models.PostMetric{
-		ReplyCount:    services.CountPostReply(item.ID),
+		ViewCount:     item.TotalViews,
+		ReplyCount:    services.CountPostReply(item.ID, viewer),
 		ReactionCount: services.CountPostReactions(item.ID),
 	}
 	item.Metric.ReactionList, err = services.ListPostReactions(database.C.Where("post_id = ?", item.ID))
@@ -96,6 +197,18 @@ func getPost(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
+	if err = loadPollMetrics(c, []*models.Post{&item}); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	if err = services.LocalizePosts([]*models.Post{&item}, negotiatePostLanguages(c)); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	if c.Query("render") == "html" {
+		item = services.RenderPostHTML(item)
+	}
+
 	return c.JSON(item)
 }
 
@@ -103,7 +216,7 @@ func searchPost(c *fiber.Ctx) error {
 	take := c.QueryInt("take", 0)
 	offset := c.QueryInt("offset", 0)
 
-	tx := database.C
+	tx := database.C.WithContext(c.UserContext())
 
 	probe := c.Query("probe")
 	if len(probe) == 0 {
@@ -128,12 +241,16 @@ func searchPost(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
+	if err = loadPollMetrics(c, items); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	if err = services.LocalizePosts(items, negotiatePostLanguages(c)); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
 	if c.QueryBool("truncate", true) {
-		for _, item := range items {
-			if item != nil {
-				item = lo.ToPtr(services.TruncatePostContent(*item))
-			}
-		}
+		services.TruncatePostsContent(items)
 	}
 
 	return c.JSON(fiber.Map{
@@ -146,30 +263,39 @@ func listPost(c *fiber.Ctx) error {
 	take := c.QueryInt("take", 0)
 	offset := c.QueryInt("offset", 0)
 
-	tx := database.C
+	tx := database.C.WithContext(c.UserContext())
 
 	var err error
 	if tx, err = universalPostFilter(c, tx); err != nil {
 		return err
 	}
 
+	order, err := universalPostOrder(c)
+	if err != nil {
+		return err
+	}
+
 	countTx := tx
 	count, err := services.CountPost(countTx)
 	if err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
-	items, err := services.ListPost(tx, take, offset, "published_at DESC")
+	items, err := services.ListPost(tx, take, offset, order)
 	if err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
+	if err = loadPollMetrics(c, items); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	if err = services.LocalizePosts(items, negotiatePostLanguages(c)); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
 	if c.QueryBool("truncate", true) {
-		for _, item := range items {
-			if item != nil {
-				item = lo.ToPtr(services.TruncatePostContent(*item))
-			}
-		}
+		services.TruncatePostsContent(items)
 	}
 
 	return c.JSON(fiber.Map{
@@ -182,7 +308,7 @@ func listPostMinimal(c *fiber.Ctx) error {
 	take := c.QueryInt("take", 0)
 	offset := c.QueryInt("offset", 0)
 
-	tx := database.C
+	tx := database.C.WithContext(c.UserContext())
 
 	var err error
 	if tx, err = universalPostFilter(c, tx); err != nil {
@@ -201,11 +327,7 @@ func listPostMinimal(c *fiber.Ctx) error {
 	}
 
 	if c.QueryBool("truncate", false) {
-		for _, item := range items {
-			if item != nil {
-				item = lo.ToPtr(services.TruncatePostContent(*item))
-			}
-		}
+		services.TruncatePostsContent(items)
 	}
 
 	return c.JSON(fiber.Map{
@@ -223,7 +345,7 @@ func listDraftPost(c *fiber.Ctx) error {
 	}
 	user := c.Locals("user").(models.Account)
 
-	tx := services.FilterPostWithAuthorDraft(database.C, user.ID)
+	tx := services.FilterPostWithAuthorDraft(database.C.WithContext(c.UserContext()), user.ID)
 
 	count, err := services.CountPost(tx)
 	if err != nil {
@@ -236,11 +358,7 @@ func listDraftPost(c *fiber.Ctx) error {
 	}
 
 	if c.QueryBool("truncate", true) {
-		for _, item := range items {
-			if item != nil {
-				item = lo.ToPtr(services.TruncatePostContent(*item))
-			}
-		}
+		services.TruncatePostsContent(items)
 	}
 
 	return c.JSON(fiber.Map{
@@ -300,9 +418,10 @@ func reactPost(c *fiber.Ctx) error {
 		AccountID: user.ID,
 	}
 
-	var res models.Post
-	if err := database.C.Where("id = ?", c.Params("postId")).Select("id").First(&res).Error; err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unable to find post to react: %v", err))
+	id, _ := c.ParamsInt("postId", 0)
+	res, err := services.GetReadablePost(uint(id), &user)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("unable to find post to react: %v", err))
 	} else {
 		reaction.PostID = &res.ID
 	}
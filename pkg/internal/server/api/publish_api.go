@@ -0,0 +1,328 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"git.solsynth.dev/hydrogen/dealer/pkg/hyper"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/gap"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/server/exts"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/services"
+	"github.com/gofiber/fiber/v2"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/samber/lo"
+)
+
+type postPublishPayload struct {
+	Type           string            `json:"type"`
+	Alias          *string           `json:"alias"`
+	Tags           []models.Tag      `json:"tags"`
+	Categories     []models.Category `json:"categories"`
+	PublishedAt    *time.Time        `json:"published_at"`
+	PublishedUntil *time.Time        `json:"published_until"`
+	VisibleUsers   []uint            `json:"visible_users_list"`
+	InvisibleUsers []uint            `json:"invisible_users_list"`
+	AudienceLists  []uint            `json:"audience_lists"`
+	Visibility     *int8             `json:"visibility"`
+	IsDraft        bool              `json:"is_draft"`
+	RealmAlias     *string           `json:"realm"`
+	ReplyTo        *uint             `json:"reply_to"`
+	RepostTo       *uint             `json:"repost_to"`
+	Language       *string           `json:"language"`
+}
+
+func bindPostBody(c *fiber.Ctx, kind services.PostType) (map[string]any, error) {
+	body := kind.NewBody()
+	if err := exts.BindAndValidate(c, body); err != nil {
+		return nil, err
+	}
+
+	mapping, err := services.EncodePostBody(kind, body)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return mapping, nil
+}
+
+// setPostLanguage sets the language the author specified, or detects it from the body.
+// The stored language is kept when the override is left out and the body wasn't changed,
+// or when the author specified it before. An empty override goes back to detecting.
+func setPostLanguage(item *models.Post, kind services.PostType, override *string, isBodyChanged bool) error {
+	if override == nil {
+		if !item.IsLanguageSpecified && (isBodyChanged || len(item.Language) == 0) {
+			item.Language, item.LanguageConfidence = services.DetectPostLanguage(kind, item.Body)
+		}
+		return nil
+	} else if len(*override) == 0 {
+		item.Language, item.LanguageConfidence = services.DetectPostLanguage(kind, item.Body)
+		item.IsLanguageSpecified = false
+		return nil
+	}
+
+	lang := services.NormalizeLanguage(*override)
+	if len(lang) == 0 {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unknown language %s", *override))
+	}
+	item.Language, item.LanguageConfidence = lang, 1
+	item.IsLanguageSpecified = true
+
+	return nil
+}
+
+func linkPostAudienceLists(user models.Account, item *models.Post, id []uint) error {
+	lists, err := services.GetAudienceListsWithIDs(user, id)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unable to target the audience lists: %v", err))
+	}
+
+	item.AudienceLists = lists
+	if len(lists) > 0 && item.Visibility != models.PostVisibilitySelected {
+		return fiber.NewError(fiber.StatusBadRequest, "only the posts with the selected visibility can target audience lists")
+	}
+
+	return nil
+}
+
+func linkPostRealm(ctx context.Context, user models.Account, item *models.Post, alias *string) error {
+	if alias == nil {
+		return nil
+	}
+
+	if realm, err := services.GetRealmWithAlias(ctx, *alias); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	} else if _, err = services.GetRealmMember(ctx, realm.ID, user.ID); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unable to post in the realm, access denied: %v", err))
+	} else {
+		item.RealmID = &realm.ID
+		item.Realm = &realm
+	}
+
+	return nil
+}
+
+func createPost(c *fiber.Ctx) error {
+	return createTypedPost(c, "")
+}
+
+func createPostWithType(kind string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return createTypedPost(c, kind)
+	}
+}
+
+func createTypedPost(c *fiber.Ctx, typeName string) error {
+	if err := gap.H.EnsureGrantedPerm(c, "CreatePosts", true); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	var data postPublishPayload
+	if err := exts.BindAndValidate(c, &data); err != nil {
+		return err
+	}
+
+	if len(typeName) == 0 {
+		typeName = data.Type
+	}
+	kind, err := services.GetPostType(typeName)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	body, err := bindPostBody(c, kind)
+	if err != nil {
+		return err
+	}
+
+	item := models.Post{
+		Alias:          data.Alias,
+		Type:           kind.Name,
+		Body:           body,
+		Tags:           data.Tags,
+		Categories:     data.Categories,
+		PublishedAt:    data.PublishedAt,
+		PublishedUntil: data.PublishedUntil,
+		IsDraft:        data.IsDraft,
+		VisibleUsers:   data.VisibleUsers,
+		InvisibleUsers: data.InvisibleUsers,
+		AuthorID:       user.ID,
+	}
+
+	if err := setPostLanguage(&item, kind, data.Language, true); err != nil {
+		return err
+	}
+
+	if item.PublishedAt == nil {
+		item.PublishedAt = lo.ToPtr(time.Now())
+	}
+
+	if data.Visibility != nil {
+		item.Visibility = *data.Visibility
+	} else if len(data.AudienceLists) > 0 {
+		item.Visibility = models.PostVisibilitySelected
+	} else {
+		item.Visibility = models.PostVisibilityAll
+	}
+
+	if err := linkPostAudienceLists(user, &item, data.AudienceLists); err != nil {
+		return err
+	}
+
+	if (data.ReplyTo != nil || data.RepostTo != nil) && !kind.IsReplyable {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("%s cannot reply or repost other posts", kind.Name))
+	}
+	if data.ReplyTo != nil {
+		if replyTo, err := services.GetReadablePost(*data.ReplyTo, &user); err != nil {
+			return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("related post was not found: %v", err))
+		} else {
+			item.ReplyID = &replyTo.ID
+		}
+	}
+	if data.RepostTo != nil {
+		if repostTo, err := services.GetReadablePost(*data.RepostTo, &user); err != nil {
+			return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("related post was not found: %v", err))
+		} else {
+			item.RepostID = &repostTo.ID
+		}
+	}
+
+	if err := linkPostRealm(c.UserContext(), user, &item, data.RealmAlias); err != nil {
+		return err
+	}
+
+	item, err = services.NewPost(user, item)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	} else {
+		_ = gap.H.RecordAuditLog(
+			user.ID,
+			"posts.new",
+			strconv.Itoa(int(item.ID)),
+			c.IP(),
+			c.Get(fiber.HeaderUserAgent),
+		)
+	}
+
+	if c.Query("render") == "html" {
+		item = services.RenderPostHTML(item)
+	}
+
+	return c.JSON(item)
+}
+
+func editPost(c *fiber.Ctx) error {
+	return editTypedPost(c, "")
+}
+
+func editPostWithType(kind string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return editTypedPost(c, kind)
+	}
+}
+
+// editTypedPost edits the post, the post must be the type when the type isn't empty.
+func editTypedPost(c *fiber.Ctx, typeName string) error {
+	id, _ := c.ParamsInt("postId", 0)
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	var data postPublishPayload
+	if err := exts.BindAndValidate(c, &data); err != nil {
+		return err
+	}
+
+	var item models.Post
+	if err := database.C.Where(models.Post{
+		BaseModel: hyper.BaseModel{ID: uint(id)},
+		AuthorID:  user.ID,
+	}).First(&item).Error; err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	if len(typeName) > 0 && item.Type != typeName {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("post is a %s, not a %s", item.Type, typeName))
+	}
+
+	if item.LockedAt != nil {
+		return fiber.NewError(fiber.StatusForbidden, "post was locked")
+	}
+
+	kind, err := services.GetPostType(item.Type)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	} else if !kind.IsEditable {
+		return fiber.NewError(fiber.StatusForbidden, fmt.Sprintf("%s cannot be edited", kind.Name))
+	}
+
+	body, err := bindPostBody(c, kind)
+	if err != nil {
+		return err
+	}
+
+	if !item.IsDraft && !data.IsDraft {
+		item.EditedAt = lo.ToPtr(time.Now())
+	}
+
+	if item.IsDraft && !data.IsDraft && data.PublishedAt == nil {
+		item.PublishedAt = lo.ToPtr(time.Now())
+	} else {
+		item.PublishedAt = data.PublishedAt
+	}
+
+	// Encoding with sorted keys makes the same body always encode to the same bytes
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+	prevBody, _ := json.Marshal(item.Body)
+	nextBody, _ := json.Marshal(body)
+	isBodyChanged := !bytes.Equal(prevBody, nextBody)
+
+	item.Alias = data.Alias
+	item.Body = body
+	item.Tags = data.Tags
+	item.Categories = data.Categories
+	item.PublishedUntil = data.PublishedUntil
+	item.IsDraft = data.IsDraft
+	item.VisibleUsers = data.VisibleUsers
+	item.InvisibleUsers = data.InvisibleUsers
+	item.Author = user
+
+	if data.Visibility != nil {
+		item.Visibility = *data.Visibility
+	}
+
+	if err := linkPostAudienceLists(user, &item, data.AudienceLists); err != nil {
+		return err
+	}
+
+	if err := setPostLanguage(&item, kind, data.Language, isBodyChanged); err != nil {
+		return err
+	}
+
+	if err := linkPostRealm(c.UserContext(), user, &item, data.RealmAlias); err != nil {
+		return err
+	}
+
+	if item, err = services.EditPost(item); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	} else {
+		_ = gap.H.RecordAuditLog(
+			user.ID,
+			"posts.edit",
+			strconv.Itoa(int(item.ID)),
+			c.IP(),
+			c.Get(fiber.HeaderUserAgent),
+		)
+	}
+
+	if c.Query("render") == "html" {
+		item = services.RenderPostHTML(item)
+	}
+
+	return c.JSON(item)
+}
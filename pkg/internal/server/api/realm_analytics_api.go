@@ -0,0 +1,144 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/gap"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// realmAnalyticsContext resolves the realm and the time range of the request,
+// only the members with enough power level can see the analytics of the realm.
+func realmAnalyticsContext(c *fiber.Ctx) (models.Realm, time.Time, error) {
+	days := c.QueryInt("days", 30)
+
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return models.Realm{}, time.Time{}, err
+	}
+	user := c.Locals("user").(models.Account)
+
+	if days <= 0 || days > 365 {
+		return models.Realm{}, time.Time{}, fiber.NewError(fiber.StatusBadRequest, "days must be between 1 and 365")
+	}
+
+	realm, err := services.GetRealmWithAlias(c.UserContext(), c.Params("realm"))
+	if err != nil {
+		return realm, time.Time{}, fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("realm was not found: %v", err))
+	} else if err := services.EnsureRealmAnalyticsPerm(c.UserContext(), realm, user); err != nil {
+		return realm, time.Time{}, fiber.NewError(fiber.StatusForbidden, err.Error())
+	}
+
+	// Start from the UTC midnight, the same as the days of the analytics, so every query counts whole days
+	since := time.Now().UTC().AddDate(0, 0, -days+1).Truncate(24 * time.Hour)
+
+	return realm, since, nil
+}
+
+func getRealmDailyPosts(c *fiber.Ctx) error {
+	realm, since, err := realmAnalyticsContext(c)
+	if err != nil {
+		return err
+	}
+
+	days, err := services.ListRealmDailyPosts(realm, since)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(days)
+}
+
+func getRealmActivePosters(c *fiber.Ctx) error {
+	take := c.QueryInt("take", 20)
+
+	realm, since, err := realmAnalyticsContext(c)
+	if err != nil {
+		return err
+	}
+
+	if take > 100 {
+		take = 100
+	}
+	posters, err := services.ListRealmActivePosters(realm, since, take)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(posters)
+}
+
+func getRealmTopPosts(c *fiber.Ctx) error {
+	take := c.QueryInt("take", 20)
+	by := c.Query("by", "views")
+
+	realm, since, err := realmAnalyticsContext(c)
+	if err != nil {
+		return err
+	}
+
+	switch by {
+	case "views", "reactions", "replies":
+	default:
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unable to rank posts by %s", by))
+	}
+
+	if take > 100 {
+		take = 100
+	}
+	posts, err := services.ListRealmTopPosts(realm, since, by, take)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(posts)
+}
+
+func getRealmTopTags(c *fiber.Ctx) error {
+	take := c.QueryInt("take", 20)
+
+	realm, since, err := realmAnalyticsContext(c)
+	if err != nil {
+		return err
+	}
+
+	if take > 100 {
+		take = 100
+	}
+	tags, err := services.ListRealmTopTags(realm, since, take)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(tags)
+}
+
+func getRealmReactionDistribution(c *fiber.Ctx) error {
+	realm, since, err := realmAnalyticsContext(c)
+	if err != nil {
+		return err
+	}
+
+	reactions, err := services.GetRealmReactionDistribution(realm, since)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(reactions)
+}
+
+func getRealmSubscriberGrowth(c *fiber.Ctx) error {
+	realm, since, err := realmAnalyticsContext(c)
+	if err != nil {
+		return err
+	}
+
+	subscribers, err := services.GetRealmSubscriberGrowth(realm, since)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(subscribers)
+}
@@ -6,14 +6,13 @@ import (
 	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
 	"git.solsynth.dev/hydrogen/interactive/pkg/internal/services"
 	"github.com/gofiber/fiber/v2"
-	"github.com/samber/lo"
 )
 
 func listRecommendationNews(c *fiber.Ctx) error {
 	take := c.QueryInt("take", 0)
 	offset := c.QueryInt("offset", 0)
 
-	tx := database.C
+	tx := database.C.WithContext(c.UserContext())
 
 	var err error
 	if tx, err = universalPostFilter(c, tx); err != nil {
@@ -37,11 +36,7 @@ func listRecommendationNews(c *fiber.Ctx) error {
 	}
 
 	if c.QueryBool("truncate", true) {
-		for _, item := range items {
-			if item != nil {
-				item = lo.ToPtr(services.TruncatePostContent(*item))
-			}
-		}
+		services.TruncatePostsContent(items)
 	}
 
 	return c.JSON(fiber.Map{
@@ -59,17 +54,17 @@ func listRecommendationFriends(c *fiber.Ctx) error {
 	take := c.QueryInt("take", 0)
 	offset := c.QueryInt("offset", 0)
 
-	tx := database.C
+	tx := database.C.WithContext(c.UserContext())
 
 	var err error
 	if tx, err = universalPostFilter(c, tx); err != nil {
 		return err
 	}
 
-	friends, _ := services.ListAccountFriends(user)
-	friendList := lo.Map(friends, func(item models.Account, index int) uint {
-		return item.ID
-	})
+	friendList, err := services.ListAccountFriendIDs(c.UserContext(), user)
+	if err != nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, err.Error())
+	}
 
 	tx = tx.Where("author_id IN ?", friendList)
 
@@ -90,11 +85,7 @@ func listRecommendationFriends(c *fiber.Ctx) error {
 	}
 
 	if c.QueryBool("truncate", true) {
-		for _, item := range items {
-			if item != nil {
-				item = lo.ToPtr(services.TruncatePostContent(*item))
-			}
-		}
+		services.TruncatePostsContent(items)
 	}
 
 	return c.JSON(fiber.Map{
@@ -107,7 +98,7 @@ func listRecommendationShuffle(c *fiber.Ctx) error {
 	take := c.QueryInt("take", 0)
 	offset := c.QueryInt("offset", 0)
 
-	tx := database.C
+	tx := database.C.WithContext(c.UserContext())
 
 	var err error
 	if tx, err = universalPostFilter(c, tx); err != nil {
@@ -126,11 +117,7 @@ func listRecommendationShuffle(c *fiber.Ctx) error {
 	}
 
 	if c.QueryBool("truncate", true) {
-		for _, item := range items {
-			if item != nil {
-				item = lo.ToPtr(services.TruncatePostContent(*item))
-			}
-		}
+		services.TruncatePostsContent(items)
 	}
 
 	return c.JSON(fiber.Map{
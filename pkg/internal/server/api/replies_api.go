@@ -14,14 +14,20 @@ func listPostReplies(c *fiber.Ctx) error {
 	take := c.QueryInt("take", 0)
 	offset := c.QueryInt("offset", 0)
 
-	tx := database.C
-	var post models.Post
-	if err := database.C.Where("id = ?", c.Params("postId")).First(&post).Error; err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unable to find post: %v", err))
-	} else {
-		tx = services.FilterPostReply(tx, post.ID)
+	var user *models.Account
+	if val, authenticated := c.Locals("user").(models.Account); authenticated {
+		user = &val
 	}
 
+	id, _ := c.ParamsInt("postId", 0)
+	post, err := services.GetReadablePost(uint(id), user)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("unable to find post: %v", err))
+	}
+
+	tx := services.FilterPostReadable(database.C.WithContext(c.UserContext()), user)
+	tx = services.FilterPostReply(tx, post.ID)
+
 	if len(c.Query("author")) > 0 {
 		var author models.Account
 		if err := database.C.Where(&hyper.BaseUser{Name: c.Query("author")}).First(&author).Error; err != nil {
@@ -57,14 +63,20 @@ func listPostFeaturedReply(c *fiber.Ctx) error {
 	take := c.QueryInt("take", 0)
 	take = max(1, min(take, 3))
 
-	tx := database.C
-	var post models.Post
-	if err := database.C.Where("id = ?", c.Params("postId")).First(&post).Error; err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unable to find post: %v", err))
-	} else {
-		tx = services.FilterPostReply(tx, post.ID)
+	var user *models.Account
+	if val, authenticated := c.Locals("user").(models.Account); authenticated {
+		user = &val
 	}
 
+	id, _ := c.ParamsInt("postId", 0)
+	post, err := services.GetReadablePost(uint(id), user)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("unable to find post: %v", err))
+	}
+
+	tx := services.FilterPostReadable(database.C.WithContext(c.UserContext()), user)
+	tx = services.FilterPostReply(tx, post.ID)
+
 	if len(c.Query("author")) > 0 {
 		var author models.Account
 		if err := database.C.Where(&hyper.BaseUser{Name: c.Query("author")}).First(&author).Error; err != nil {
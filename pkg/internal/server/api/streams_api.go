@@ -0,0 +1,129 @@
+package api
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.solsynth.dev/hydrogen/dealer/pkg/hyper"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/services"
+	"github.com/gofiber/fiber/v2"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/samber/lo"
+)
+
+type postStreamMessage struct {
+	services.PostEvent
+	Post *models.Post `json:"post,omitempty"`
+}
+
+func bindPostEventFilter(c *fiber.Ctx) (services.PostEventFilter, error) {
+	filter := services.PostEventFilter{
+		IsFeed:    c.QueryBool("feed", len(c.Query("threads")) == 0),
+		WithReply: !c.QueryBool("noReply", true),
+	}
+
+	if len(c.Query("realm")) > 0 {
+		if realm, err := services.GetRealmWithAlias(c.UserContext(), c.Query("realm")); err != nil {
+			return filter, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("realm was not found: %v", err))
+		} else {
+			filter.RealmID = &realm.ID
+		}
+	}
+	if len(c.Query("author")) > 0 {
+		var author models.Account
+		if err := database.C.Where(&hyper.BaseUser{Name: c.Query("author")}).First(&author).Error; err != nil {
+			return filter, fiber.NewError(fiber.StatusNotFound, err.Error())
+		}
+		filter.AuthorID = &author.ID
+	}
+	if len(c.Query("tag")) > 0 {
+		filter.Tag = lo.ToPtr(c.Query("tag"))
+	}
+	if len(c.Query("category")) > 0 {
+		filter.Category = lo.ToPtr(c.Query("category"))
+	}
+
+	if len(c.Query("threads")) > 0 {
+		for _, item := range strings.Split(c.Query("threads"), ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(item))
+			if err != nil || id <= 0 {
+				return filter, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("invalid thread id %s", item))
+			}
+			filter.Threads = append(filter.Threads, uint(id))
+		}
+	}
+
+	return filter, nil
+}
+
+// streamPostEvents pushes the changes of the subscribed feeds and threads as server-sent events,
+// every event is checked against the visibility rules of the connected user before sending.
+func streamPostEvents(c *fiber.Ctx) error {
+	var user *models.Account
+	if val, authenticated := c.Locals("user").(models.Account); authenticated {
+		user = &val
+	}
+
+	filter, err := bindPostEventFilter(c)
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	viewer := services.NewPostStreamViewer(user)
+	events, unsubscribe := services.SubscribePostEvents()
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+
+		heartbeat := time.NewTicker(30 * time.Second)
+		defer heartbeat.Stop()
+
+		_, _ = fmt.Fprint(w, ": connected\n\n")
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				if !filter.Match(event) {
+					continue
+				}
+				post, ok := viewer.GetStreamablePost(event)
+				if !ok {
+					continue
+				}
+
+				message := postStreamMessage{PostEvent: event}
+				if event.Type == services.PostEventCreated || event.Type == services.PostEventEdited {
+					message.Post = &post
+				}
+				data, err := jsoniter.Marshal(message)
+				if err != nil {
+					continue
+				}
+				_, _ = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+			case <-heartbeat.C:
+				_, _ = fmt.Fprint(w, ": ping\n\n")
+			}
+
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
@@ -83,7 +83,7 @@ func getSubscriptionOnRealm(c *fiber.Ctx) error {
 	user := c.Locals("user").(models.Account)
 
 	realmId, err := c.ParamsInt("realmId", 0)
-	realm, err := services.GetRealmWithID(uint(realmId))
+	realm, err := services.GetRealmWithID(c.UserContext(), uint(realmId))
 	if err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unable to get realm: %v", err))
 	}
@@ -189,7 +189,7 @@ func subscribeToRealm(c *fiber.Ctx) error {
 	user := c.Locals("user").(models.Account)
 
 	realmId, err := c.ParamsInt("realmId", 0)
-	realm, err := services.GetRealmWithID(uint(realmId))
+	realm, err := services.GetRealmWithID(c.UserContext(), uint(realmId))
 	if err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unable to get realm: %v", err))
 	}
@@ -301,7 +301,7 @@ func unsubscribeFromRealm(c *fiber.Ctx) error {
 	user := c.Locals("user").(models.Account)
 
 	realmId, err := c.ParamsInt("realmId", 0)
-	realm, err := services.GetRealmWithID(uint(realmId))
+	realm, err := services.GetRealmWithID(c.UserContext(), uint(realmId))
 	if err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unable to get realm: %v", err))
 	}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/gap"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+func getTranslatablePost(c *fiber.Ctx, user models.Account) (models.Post, string, error) {
+	id, _ := c.ParamsInt("postId", 0)
+
+	lang := services.NormalizeLanguage(c.Params("lang"))
+	if len(lang) == 0 {
+		return models.Post{}, lang, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unknown language %s", c.Params("lang")))
+	}
+
+	tx := services.FilterPostReadable(database.C, &user)
+
+	item, err := services.GetPost(tx, uint(id))
+	if err != nil {
+		return item, lang, fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("unable to find post: %v", err))
+	}
+
+	if item.AuthorID != user.ID {
+		if err := gap.H.EnsureGrantedPerm(c, "TranslatePosts", true); err != nil {
+			return item, lang, err
+		}
+	}
+
+	return item, lang, nil
+}
+
+func listPostTranslations(c *fiber.Ctx) error {
+	id, _ := c.ParamsInt("postId", 0)
+
+	tx := database.C
+
+	if user, authenticated := c.Locals("user").(models.Account); authenticated {
+		tx = services.FilterPostReadable(tx, &user)
+	} else {
+		tx = services.FilterPostReadable(tx, nil)
+	}
+
+	item, err := services.GetPost(tx, uint(id))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("unable to find post: %v", err))
+	}
+
+	translations, err := services.ListPostTranslations(item)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(translations)
+}
+
+func setPostTranslation(c *fiber.Ctx) error {
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	item, lang, err := getTranslatablePost(c, user)
+	if err != nil {
+		return err
+	}
+
+	kind, err := services.GetPostType(item.Type)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	body, err := bindPostBody(c, kind)
+	if err != nil {
+		return err
+	}
+
+	translation, err := services.SetPostTranslation(user, item, lang, body)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	} else {
+		_ = gap.H.RecordAuditLog(
+			user.ID,
+			"posts.translations.set",
+			strconv.Itoa(int(item.ID)),
+			c.IP(),
+			c.Get(fiber.HeaderUserAgent),
+		)
+	}
+
+	return c.JSON(translation)
+}
+
+func deletePostTranslation(c *fiber.Ctx) error {
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	item, lang, err := getTranslatablePost(c, user)
+	if err != nil {
+		return err
+	}
+
+	if err := services.DeletePostTranslation(item, lang); err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	} else {
+		_ = gap.H.RecordAuditLog(
+			user.ID,
+			"posts.translations.delete",
+			strconv.Itoa(int(item.ID)),
+			c.IP(),
+			c.Get(fiber.HeaderUserAgent),
+		)
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
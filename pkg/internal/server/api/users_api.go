@@ -1,10 +1,13 @@
 package api
 
 import (
+	"time"
+
 	"git.solsynth.dev/hydrogen/dealer/pkg/hyper"
 	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
 	"git.solsynth.dev/hydrogen/interactive/pkg/internal/gap"
 	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/server/exts"
 	"git.solsynth.dev/hydrogen/interactive/pkg/internal/services"
 	"github.com/gofiber/fiber/v2"
 )
@@ -25,6 +28,65 @@ func getUserinfo(c *fiber.Ctx) error {
 	return c.JSON(data)
 }
 
+func getUserAnalytics(c *fiber.Ctx) error {
+	days := c.QueryInt("days", 30)
+	take := c.QueryInt("take", 20)
+
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	if days <= 0 || days > 365 {
+		return fiber.NewError(fiber.StatusBadRequest, "days must be between 1 and 365")
+	}
+
+	since := time.Now().AddDate(0, 0, -days+1)
+	analytics, err := services.GetAccountAnalytics(user, since, take)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(analytics)
+}
+
+func getLanguagePreference(c *fiber.Ctx) error {
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	pref, err := services.GetLanguagePreference(user)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(pref)
+}
+
+func setLanguagePreference(c *fiber.Ctx) error {
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	var data struct {
+		Preferred []string `json:"preferred"`
+		Excluded  []string `json:"excluded"`
+	}
+
+	if err := exts.BindAndValidate(c, &data); err != nil {
+		return err
+	}
+
+	pref, err := services.SetLanguagePreference(user, data.Preferred, data.Excluded)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(pref)
+}
+
 func getOthersInfo(c *fiber.Ctx) error {
 	account := c.Params("account")
 
@@ -48,7 +110,12 @@ func listOthersPinnedPost(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
-	tx := services.FilterPostDraft(database.C)
+	tx := database.C
+	if viewer, authenticated := c.Locals("user").(models.Account); authenticated {
+		tx = services.FilterPostReadable(tx, &viewer)
+	} else {
+		tx = services.FilterPostReadable(tx, nil)
+	}
 	tx = tx.Where("author_id = ?", user.ID)
 	tx = tx.Where("pinned_at IS NOT NULL")
 
@@ -0,0 +1,273 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/gap"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/server/exts"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+type webhookPayload struct {
+	URL      string   `json:"url" validate:"required,url"`
+	Events   []string `json:"events" validate:"required,min=1"`
+	IsActive *bool    `json:"is_active"`
+}
+
+func getWebhookWithContext(c *fiber.Ctx, user models.Account) (models.Webhook, error) {
+	id, _ := c.ParamsInt("hookId", 0)
+	hook, err := services.GetWebhookWithUser(uint(id), user)
+	if err != nil {
+		return hook, fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	if hook.RealmID != nil {
+		if err := services.EnsureRealmWebhookPerm(c.UserContext(), *hook.RealmID, user); err != nil {
+			return hook, fiber.NewError(fiber.StatusForbidden, err.Error())
+		}
+	}
+
+	return hook, nil
+}
+
+func listWebhooks(c *fiber.Ctx) error {
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	hooks, err := services.ListWebhooks(user)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(hooks)
+}
+
+func getWebhook(c *fiber.Ctx) error {
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	hook, err := getWebhookWithContext(c, user)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(hook)
+}
+
+func newWebhook(c *fiber.Ctx) error {
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	var data struct {
+		webhookPayload
+		RealmAlias *string `json:"realm"`
+	}
+
+	if err := exts.BindAndValidate(c, &data); err != nil {
+		return err
+	}
+
+	hook := models.Webhook{
+		URL:      data.URL,
+		Events:   data.Events,
+		IsActive: data.IsActive == nil || *data.IsActive,
+	}
+
+	if data.RealmAlias != nil {
+		if realm, err := services.GetRealmWithAlias(c.UserContext(), *data.RealmAlias); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("realm was not found: %v", err))
+		} else {
+			hook.RealmID = &realm.ID
+		}
+	}
+
+	hook, err := services.NewWebhook(user, hook)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	} else {
+		_ = gap.H.RecordAuditLog(
+			user.ID,
+			"webhooks.new",
+			strconv.Itoa(int(hook.ID)),
+			c.IP(),
+			c.Get(fiber.HeaderUserAgent),
+		)
+	}
+
+	// The secret only shows up once, when the webhook is created or the secret is rotated
+	return c.JSON(fiber.Map{
+		"webhook": hook,
+		"secret":  hook.Secret,
+	})
+}
+
+func editWebhook(c *fiber.Ctx) error {
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	hook, err := getWebhookWithContext(c, user)
+	if err != nil {
+		return err
+	}
+
+	var data webhookPayload
+
+	if err := exts.BindAndValidate(c, &data); err != nil {
+		return err
+	}
+
+	hook.URL = data.URL
+	hook.Events = data.Events
+	if data.IsActive != nil {
+		hook.IsActive = *data.IsActive
+	}
+
+	if hook, err = services.EditWebhook(hook); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(hook)
+}
+
+func rotateWebhookSecret(c *fiber.Ctx) error {
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	hook, err := getWebhookWithContext(c, user)
+	if err != nil {
+		return err
+	}
+
+	if hook, err = services.RotateWebhookSecret(hook); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	} else {
+		_ = gap.H.RecordAuditLog(
+			user.ID,
+			"webhooks.secret.rotate",
+			strconv.Itoa(int(hook.ID)),
+			c.IP(),
+			c.Get(fiber.HeaderUserAgent),
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"webhook": hook,
+		"secret":  hook.Secret,
+	})
+}
+
+func deleteWebhook(c *fiber.Ctx) error {
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	hook, err := getWebhookWithContext(c, user)
+	if err != nil {
+		return err
+	}
+
+	if err := services.DeleteWebhook(hook); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	} else {
+		_ = gap.H.RecordAuditLog(
+			user.ID,
+			"webhooks.delete",
+			strconv.Itoa(int(hook.ID)),
+			c.IP(),
+			c.Get(fiber.HeaderUserAgent),
+		)
+	}
+
+	return c.JSON(hook)
+}
+
+func listWebhookDeliveries(c *fiber.Ctx) error {
+	take := c.QueryInt("take", 0)
+	offset := c.QueryInt("offset", 0)
+
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	hook, err := getWebhookWithContext(c, user)
+	if err != nil {
+		return err
+	}
+
+	count, err := services.CountWebhookDeliveries(hook)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	items, err := services.ListWebhookDeliveries(hook, take, offset)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"count": count,
+		"data":  items,
+	})
+}
+
+func getWebhookDelivery(c *fiber.Ctx) error {
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	hook, err := getWebhookWithContext(c, user)
+	if err != nil {
+		return err
+	}
+
+	id, _ := c.ParamsInt("deliveryId", 0)
+	delivery, err := services.GetWebhookDelivery(hook, uint(id))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	return c.JSON(delivery)
+}
+
+func redeliverWebhook(c *fiber.Ctx) error {
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	hook, err := getWebhookWithContext(c, user)
+	if err != nil {
+		return err
+	} else if !hook.IsActive {
+		return fiber.NewError(fiber.StatusBadRequest, "webhook is disabled")
+	}
+
+	id, _ := c.ParamsInt("deliveryId", 0)
+	delivery, err := services.GetWebhookDelivery(hook, uint(id))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	redelivery, err := services.RedeliverWebhook(hook, delivery)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(redelivery)
+}
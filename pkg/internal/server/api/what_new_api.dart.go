@@ -2,41 +2,90 @@ package api
 
 import (
 	"fmt"
+	"time"
+
 	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
 	"git.solsynth.dev/hydrogen/interactive/pkg/internal/gap"
 	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/server/exts"
 	"git.solsynth.dev/hydrogen/interactive/pkg/internal/services"
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 )
 
+// whatsNewFilter builds the query of the feed and returns the key of its read marker,
+// the feed is global unless the realm or the subscription feed is asked.
+func whatsNewFilter(c *fiber.Ctx, user models.Account, feed, realm string) (*gorm.DB, string, error) {
+	tx := services.FilterPostReadable(database.C.WithContext(c.UserContext()), &user)
+	tx = services.FilterPostWithPublishedAt(tx, time.Now())
+	tx = languagePostFilter(c, tx)
+	tx = mutePostFilter(c, tx)
+
+	if len(realm) > 0 {
+		feed = models.ReadMarkerFeedRealm
+	}
+
+	switch feed {
+	case "", models.ReadMarkerFeedGlobal:
+		return tx, models.ReadMarkerFeedGlobal, nil
+	case models.ReadMarkerFeedRealm:
+		if realm, err := services.GetRealmWithAlias(c.UserContext(), realm); err != nil {
+			return tx, feed, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("realm was not found: %v", err))
+		} else {
+			return services.FilterPostWithRealm(tx, realm.ID), services.GetRealmReadMarkerFeed(realm), nil
+		}
+	case models.ReadMarkerFeedSubscription:
+		return services.FilterPostWithSubscriptions(tx, user), feed, nil
+	}
+
+	return tx, feed, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unknown feed %s", feed))
+}
+
+// whatsNewPivot returns the stored read marker of the feed, and the pivot to list the posts after.
+// The pivot is the post passed by the client, or the stored read marker.
+func whatsNewPivot(c *fiber.Ctx, user models.Account, feed string) (models.ReadMarker, models.ReadMarker, error) {
+	marker, err := services.GetReadMarker(user, feed)
+	if err != nil {
+		return marker, marker, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	pivot := c.QueryInt("pivot", -1)
+	if pivot < -1 {
+		return marker, marker, fiber.NewError(fiber.StatusBadRequest, "pivot must be greater than zero")
+	} else if pivot == -1 {
+		return marker, marker, nil
+	} else if pivot == 0 {
+		return marker, models.ReadMarker{}, nil
+	}
+
+	post, err := services.GetPostReadMarker(uint(pivot))
+	if err != nil {
+		return marker, post, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return marker, post, nil
+}
+
 func getWhatsNew(c *fiber.Ctx) error {
+	take := c.QueryInt("take", 10)
+	offset := c.QueryInt("offset", 0)
+
 	if err := gap.H.EnsureAuthenticated(c); err != nil {
 		return err
 	}
 	user := c.Locals("user").(models.Account)
 
-	pivot := c.QueryInt("pivot", 0)
-	if pivot < 0 {
-		return fiber.NewError(fiber.StatusBadRequest, "pivot must be greater than zero")
+	tx, feed, err := whatsNewFilter(c, user, c.Query("feed"), c.Query("realm"))
+	if err != nil {
+		return err
 	}
 
-	realm := c.Query("realm")
-
-	tx := services.FilterPostDraft(database.C)
-	tx = services.FilterPostWithUserContext(tx, &user)
-
-	tx = tx.Where("id > ?", pivot)
-
-	if len(realm) > 0 {
-		if realm, err := services.GetRealmWithAlias(realm); err != nil {
-			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("realm was not found: %v", err))
-		} else {
-			tx = services.FilterPostWithRealm(tx, realm.ID)
-		}
+	marker, pivot, err := whatsNewPivot(c, user, feed)
+	if err != nil {
+		return err
 	}
+	tx = services.FilterPostUnread(tx, pivot).Session(&gorm.Session{})
 
-	countTx := tx
-	count, err := services.CountPost(countTx)
+	count, err := services.CountPost(tx)
 	if err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
@@ -46,13 +95,83 @@ func getWhatsNew(c *fiber.Ctx) error {
 		order = "published_at DESC, (COALESCE(total_upvote, 0) - COALESCE(total_downvote, 0)) DESC"
 	}
 
-	items, err := services.ListPost(tx, 10, 0, order)
+	if take > 100 {
+		take = 100
+	}
+	items, err := services.ListPost(tx, take, offset, order)
 	if err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
 	return c.JSON(fiber.Map{
-		"count": count,
-		"data":  items,
+		"count":  count,
+		"data":   items,
+		"marker": marker,
 	})
 }
+
+func getWhatsNewCount(c *fiber.Ctx) error {
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	tx, feed, err := whatsNewFilter(c, user, c.Query("feed"), c.Query("realm"))
+	if err != nil {
+		return err
+	}
+
+	marker, pivot, err := whatsNewPivot(c, user, feed)
+	if err != nil {
+		return err
+	}
+
+	count, err := services.CountPost(services.FilterPostUnread(tx, pivot))
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"count":  count,
+		"marker": marker,
+	})
+}
+
+func markWhatsNewAsRead(c *fiber.Ctx) error {
+	if err := gap.H.EnsureAuthenticated(c); err != nil {
+		return err
+	}
+	user := c.Locals("user").(models.Account)
+
+	var data struct {
+		Feed  string `json:"feed"`
+		Realm string `json:"realm"`
+		Pivot *uint  `json:"pivot"`
+	}
+
+	if err := exts.BindAndValidate(c, &data); err != nil {
+		return err
+	}
+
+	tx, feed, err := whatsNewFilter(c, user, data.Feed, data.Realm)
+	if err != nil {
+		return err
+	}
+
+	// Everything in the feed is read when the pivot is left empty
+	var pivot models.ReadMarker
+	if data.Pivot != nil {
+		if pivot, err = services.GetPostReadMarker(*data.Pivot); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+	} else if pivot, err = services.GetLatestPostReadMarker(tx); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	marker, err := services.MarkFeedAsRead(user, feed, pivot)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(marker)
+}
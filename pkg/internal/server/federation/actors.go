@@ -0,0 +1,147 @@
+package federation
+
+import (
+	"fmt"
+
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+)
+
+const outboxPageSize = 20
+
+func getWebFinger(c *fiber.Ctx) error {
+	doc, err := services.ResolveWebFinger(c.Query("resource"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	return c.JSON(doc, "application/jrd+json")
+}
+
+func getAccountActor(c *fiber.Ctx) error {
+	account, err := services.GetLocalAccountWithName(c.Params("name"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	actor, err := services.NewAccountActor(account)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(actor, services.ActivityContentType)
+}
+
+func getRealmActor(c *fiber.Ctx) error {
+	realm, err := services.GetPublicRealmWithAlias(c.UserContext(), c.Params("alias"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	actor, err := services.NewRealmActor(realm)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(actor, services.ActivityContentType)
+}
+
+func renderOutbox(c *fiber.Ctx, iri string, tx *gorm.DB) error {
+	page := c.QueryInt("page", 0)
+
+	count, err := services.CountFederatedPosts(tx.Session(&gorm.Session{}))
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	outbox := iri + "/outbox"
+	if page <= 0 {
+		return c.JSON(services.ActivityObject{
+			"@context":   services.ActivityStreamsContext,
+			"id":         outbox,
+			"type":       "OrderedCollection",
+			"totalItems": count,
+			"first":      outbox + "?page=1",
+		}, services.ActivityContentType)
+	}
+
+	items, err := services.ListFederatedPosts(tx, outboxPageSize, (page-1)*outboxPageSize)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	collection := services.ActivityObject{
+		"@context": services.ActivityStreamsContext,
+		"id":       fmt.Sprintf("%s?page=%d", outbox, page),
+		"type":     "OrderedCollectionPage",
+		"partOf":   outbox,
+		"orderedItems": lo.Map(items, func(item *models.Post, index int) services.ActivityObject {
+			return services.NewPostActivity(*item)
+		}),
+	}
+	if int64(page*outboxPageSize) < count {
+		collection["next"] = fmt.Sprintf("%s?page=%d", outbox, page+1)
+	}
+	if page > 1 {
+		collection["prev"] = fmt.Sprintf("%s?page=%d", outbox, page-1)
+	}
+
+	return c.JSON(collection, services.ActivityContentType)
+}
+
+func getAccountOutbox(c *fiber.Ctx) error {
+	account, err := services.GetLocalAccountWithName(c.Params("name"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	tx := database.C.Where("author_id = ?", account.ID)
+	return renderOutbox(c, services.GetAccountActorIRI(account), tx)
+}
+
+func getRealmOutbox(c *fiber.Ctx) error {
+	realm, err := services.GetPublicRealmWithAlias(c.UserContext(), c.Params("alias"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	tx := services.FilterPostWithRealm(database.C, realm.ID)
+	return renderOutbox(c, services.GetRealmActorIRI(realm), tx)
+}
+
+func renderFollowers(c *fiber.Ctx, iri string) error {
+	count, err := services.CountRemoteFollowers(iri)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// Only the count is exposed, the followers list is kept private
+	return c.JSON(services.ActivityObject{
+		"@context":   services.ActivityStreamsContext,
+		"id":         iri + "/followers",
+		"type":       "OrderedCollection",
+		"totalItems": count,
+	}, services.ActivityContentType)
+}
+
+func getAccountFollowers(c *fiber.Ctx) error {
+	account, err := services.GetLocalAccountWithName(c.Params("name"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	return renderFollowers(c, services.GetAccountActorIRI(account))
+}
+
+func getRealmFollowers(c *fiber.Ctx) error {
+	realm, err := services.GetPublicRealmWithAlias(c.UserContext(), c.Params("alias"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	return renderFollowers(c, services.GetRealmActorIRI(realm))
+}
@@ -0,0 +1,33 @@
+package federation
+
+import (
+	"fmt"
+
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/services"
+	"github.com/gofiber/fiber/v2"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/rs/zerolog/log"
+)
+
+func postInbox(c *fiber.Ctx) error {
+	body := c.Body()
+
+	actor, err := services.VerifyActivityRequest(c.Method(), c.OriginalURL(), func(name string) string {
+		return c.Get(name)
+	}, body)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, fmt.Sprintf("unable to verify request signature: %v", err))
+	}
+
+	var activity services.ActivityObject
+	if err := jsoniter.Unmarshal(body, &activity); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	if err := services.HandleInboxActivity(actor, activity); err != nil {
+		log.Warn().Err(err).Str("actor", actor.IRI).Msg("Unable to handle inbox activity...")
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	return c.SendStatus(fiber.StatusAccepted)
+}
@@ -0,0 +1,33 @@
+package federation
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+func MapFederation(app *fiber.App) {
+	app.Get("/.well-known/webfinger", getWebFinger)
+
+	ap := app.Group("/ap").Name("ActivityPub")
+	{
+		ap.Post("/inbox", postInbox)
+
+		ap.Get("/posts/:postId", getPostObject)
+		ap.Get("/posts/:postId/activity", getPostActivity)
+
+		users := ap.Group("/users").Name("ActivityPub Users")
+		{
+			users.Get("/:name", getAccountActor)
+			users.Get("/:name/outbox", getAccountOutbox)
+			users.Get("/:name/followers", getAccountFollowers)
+			users.Post("/:name/inbox", postInbox)
+		}
+
+		realms := ap.Group("/realms").Name("ActivityPub Realms")
+		{
+			realms.Get("/:alias", getRealmActor)
+			realms.Get("/:alias/outbox", getRealmOutbox)
+			realms.Get("/:alias/followers", getRealmFollowers)
+			realms.Post("/:alias/inbox", postInbox)
+		}
+	}
+}
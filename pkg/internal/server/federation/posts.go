@@ -0,0 +1,47 @@
+package federation
+
+import (
+	"fmt"
+
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+func getFederatedPost(c *fiber.Ctx) (models.Post, error) {
+	id, _ := c.ParamsInt("postId", 0)
+
+	tx := services.FilterPostPublic(database.C)
+	tx = tx.Where("federated_iri IS NULL")
+
+	item, err := services.GetPost(tx, uint(id))
+	if err != nil {
+		return item, fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("unable to find post: %v", err))
+	}
+	return item, nil
+}
+
+func getPostObject(c *fiber.Ctx) error {
+	item, err := getFederatedPost(c)
+	if err != nil {
+		return err
+	}
+
+	object := services.NewPostObject(item)
+	object["@context"] = services.ActivityStreamsContext
+
+	return c.JSON(object, services.ActivityContentType)
+}
+
+func getPostActivity(c *fiber.Ctx) error {
+	item, err := getFederatedPost(c)
+	if err != nil {
+		return err
+	}
+
+	activity := services.NewPostActivity(item)
+	activity["@context"] = services.ActivityStreamsContext
+
+	return c.JSON(activity, services.ActivityContentType)
+}
@@ -0,0 +1,122 @@
+package feeds
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// isFeedFresh checks the conditional request headers, the etag wins when both are sent.
+func isFeedFresh(c *fiber.Ctx, etag string, lastModified time.Time) bool {
+	if c.Get(fiber.HeaderCacheControl) == "no-cache" {
+		return false
+	}
+
+	if noneMatch := c.Get(fiber.HeaderIfNoneMatch); len(noneMatch) > 0 {
+		for _, tag := range strings.Split(noneMatch, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	if modifiedSince := c.Get(fiber.HeaderIfModifiedSince); len(modifiedSince) > 0 {
+		since, err := http.ParseTime(modifiedSince)
+		return err == nil && !lastModified.After(since)
+	}
+
+	return false
+}
+
+func renderFeed(c *fiber.Ctx, info services.PostFeedInfo, tx *gorm.DB) error {
+	format := c.Query("format", services.FeedFormatRSS)
+	contentType, ok := services.FeedContentTypes[format]
+	if !ok {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unknown feed format %s", format))
+	}
+
+	info.Link = c.BaseURL() + c.Path()
+	tx = services.FilterPostFeed(tx).Session(&gorm.Session{})
+
+	lastModified, etag, err := services.GetPostFeedVersion(tx, info.Link, format)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	info.Updated = lastModified
+	c.Set(fiber.HeaderETag, etag)
+	c.Set(fiber.HeaderLastModified, lastModified.Format(http.TimeFormat))
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	if isFeedFresh(c, etag, lastModified) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	items, err := services.ListPostFeedItems(tx)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	out, err := services.RenderPostFeed(info, items, format)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	return c.SendString(out)
+}
+
+func getUserFeed(c *fiber.Ctx) error {
+	account, err := services.GetLocalAccountWithName(c.Params("name"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	return renderFeed(c, services.PostFeedInfo{
+		Title:       fmt.Sprintf("%s (@%s)", account.Nick, account.Name),
+		Description: fmt.Sprintf("Posts from %s", account.Nick),
+	}, database.C.Where("author_id = ?", account.ID))
+}
+
+func getTagFeed(c *fiber.Ctx) error {
+	tag, err := services.GetTag(c.Params("tag"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	return renderFeed(c, services.PostFeedInfo{
+		Title:       fmt.Sprintf("#%s", tag.Alias),
+		Description: tag.Description,
+	}, services.FilterPostWithTag(database.C, tag.Alias))
+}
+
+func getCategoryFeed(c *fiber.Ctx) error {
+	category, err := services.GetCategory(c.Params("category"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	return renderFeed(c, services.PostFeedInfo{
+		Title:       category.Name,
+		Description: category.Description,
+	}, services.FilterPostWithCategory(database.C, category.Alias))
+}
+
+func getRealmFeed(c *fiber.Ctx) error {
+	realm, err := services.GetPublicRealmWithAlias(c.UserContext(), c.Params("alias"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	return renderFeed(c, services.PostFeedInfo{
+		Title:       realm.Name,
+		Description: realm.Description,
+	}, services.FilterPostWithRealm(database.C, realm.ID))
+}
@@ -0,0 +1,15 @@
+package feeds
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+func MapFeeds(app *fiber.App, baseURL string) {
+	feeds := app.Group(baseURL).Name("Feeds")
+	{
+		feeds.Get("/users/:name", getUserFeed)
+		feeds.Get("/tags/:tag", getTagFeed)
+		feeds.Get("/categories/:category", getCategoryFeed)
+		feeds.Get("/realms/:alias", getRealmFeed)
+	}
+}
@@ -6,13 +6,19 @@ import (
 	"git.solsynth.dev/hydrogen/dealer/pkg/hyper"
 	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
 	"git.solsynth.dev/hydrogen/interactive/pkg/internal/gap"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/metrics"
 	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
 	"git.solsynth.dev/hydrogen/interactive/pkg/internal/server/api"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/server/federation"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/server/feeds"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/services"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/tracing"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/idempotency"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	jsoniter "github.com/json-iterator/go"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
 )
@@ -32,6 +38,14 @@ func NewServer() {
 		EnablePrintRoutes:     viper.GetBool("debug.print_routes"),
 	})
 
+	if viper.GetBool("metrics.enabled") {
+		app.Use(metrics.HttpMiddleware)
+	}
+
+	if tracing.IsEnabled() {
+		app.Use(tracing.HttpMiddleware)
+	}
+
 	app.Use(idempotency.New())
 	app.Use(cors.New(cors.Config{
 		AllowCredentials: true,
@@ -67,6 +81,32 @@ func NewServer() {
 	))
 
 	api.MapAPIs(app, "/api")
+	feeds.MapFeeds(app, "/feeds")
+
+	if services.IsFederationEnabled() {
+		federation.MapFederation(app)
+	}
+}
+
+var metricsApp *fiber.App
+
+// NewMetricsServer serves the metrics on the address of their own,
+// so they are never exposed with the public api.
+func NewMetricsServer() {
+	prometheus.MustRegister(services.CacheCollector{})
+
+	metricsApp = fiber.New(fiber.Config{
+		DisableStartupMessage: true,
+		ServerHeader:          "Hydrogen.Interactive",
+		AppName:               "Hydrogen.Interactive Metrics",
+	})
+	metricsApp.Get("/metrics", metrics.TokenMiddleware(viper.GetString("metrics.token")), metrics.HttpHandler())
+}
+
+func ListenMetrics() {
+	if err := metricsApp.Listen(viper.GetString("metrics.bind")); err != nil {
+		log.Fatal().Err(err).Msg("An error occurred when starting metrics server...")
+	}
 }
 
 func Listen() {
@@ -9,9 +9,9 @@ import (
 	"git.solsynth.dev/hydrogen/dealer/pkg/proto"
 	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
 	"git.solsynth.dev/hydrogen/interactive/pkg/internal/gap"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/metrics"
 	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
 	"github.com/rs/zerolog/log"
-	"github.com/samber/lo"
 )
 
 func GetAccountWithID(id uint) (models.Account, error) {
@@ -22,26 +22,24 @@ func GetAccountWithID(id uint) (models.Account, error) {
 	return account, nil
 }
 
-func ListAccountFriends(user models.Account) ([]models.Account, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
-	defer cancel()
-
-	pc, err := gap.H.GetServiceGrpcConn(hyper.ServiceTypeAuthProvider)
-	if err != nil {
-		return nil, fmt.Errorf("failed to listing account friends: %v", err)
+// GetLocalAccountWithName finds the account by name, the accounts mapped from remote actors are excluded.
+func GetLocalAccountWithName(name string) (models.Account, error) {
+	var account models.Account
+	if err := database.C.
+		Where(&hyper.BaseUser{Name: name}).
+		Where("id < ?", RemoteAccountIDBase).
+		First(&account).Error; err != nil {
+		return account, err
 	}
-	result, err := proto.NewAuthClient(pc).ListUserFriends(ctx, &proto.ListUserRelativeRequest{
-		UserId:    uint64(user.ID),
-		IsRelated: true,
-	})
+	return account, nil
+}
+
+func ListAccountFriends(user models.Account) ([]models.Account, error) {
+	out, err := ListAccountFriendIDs(context.Background(), user)
 	if err != nil {
-		return nil, fmt.Errorf("failed to listing account friends: %v", err)
+		return nil, err
 	}
 
-	out := lo.Map(result.Data, func(item *proto.SimpleUserInfo, index int) uint {
-		return uint(item.Id)
-	})
-
 	var accounts []models.Account
 	if err = database.C.Where("id IN ?", out).Find(&accounts).Error; err != nil {
 		return nil, fmt.Errorf("failed to linking listed account friends: %v", err)
@@ -51,24 +49,10 @@ func ListAccountFriends(user models.Account) ([]models.Account, error) {
 }
 
 func ListAccountBlockedUsers(user models.Account) ([]models.Account, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
-	defer cancel()
-
-	pc, err := gap.H.GetServiceGrpcConn(hyper.ServiceTypeAuthProvider)
+	out, err := ListAccountBlockedUserIDs(context.Background(), user)
 	if err != nil {
-		return nil, fmt.Errorf("failed to listing account blocked users: %v", err)
+		return nil, err
 	}
-	result, err := proto.NewAuthClient(pc).ListUserBlocklist(ctx, &proto.ListUserRelativeRequest{
-		UserId:    uint64(user.ID),
-		IsRelated: true,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to listing account blocked users: %v", err)
-	}
-
-	out := lo.Map(result.Data, func(item *proto.SimpleUserInfo, index int) uint {
-		return uint(item.Id)
-	})
 
 	var accounts []models.Account
 	if err = database.C.Where("id IN ?", out).Find(&accounts).Error; err != nil {
@@ -96,6 +80,8 @@ func NotifyPosterAccount(user models.Account, post models.Post, title, body stri
 	if err != nil {
 		return err
 	}
+	metrics.ObserveNotificationFanout("interactive.feedback", 1)
+	ctx, done := observeGrpc(ctx, "Notifier", "NotifyUser")
 	_, err = proto.NewNotifierClient(pc).NotifyUser(ctx, &proto.NotifyUserRequest{
 		UserId: uint64(user.ID),
 		Notify: &proto.NotifyRequest{
@@ -110,6 +96,7 @@ func NotifyPosterAccount(user models.Account, post models.Post, title, body stri
 			IsForcePush: true,
 		},
 	})
+	done(err)
 	if err != nil {
 		log.Warn().Err(err).Msg("An error occurred when notify account...")
 	} else {
@@ -0,0 +1,677 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/rs/zerolog/log"
+	"github.com/samber/lo"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+const (
+	ActivityStreamsContext = "https://www.w3.org/ns/activitystreams"
+	ActivityStreamsPublic  = "https://www.w3.org/ns/activitystreams#Public"
+	ActivityContentType    = "application/activity+json"
+
+	// RemoteAccountIDBase keeps the accounts mapped from remote actors away from
+	// the ids of the accounts from the auth provider.
+	RemoteAccountIDBase = uint(1) << 40
+
+	activityBodyLimit = 1024 * 1024
+)
+
+type ActivityObject = map[string]any
+
+// getFederationClient builds the client once, it is shared by the deliveries and the inbox handling.
+var getFederationClient = sync.OnceValue(newFederationClient)
+
+func newFederationClient() *http.Client {
+	if viper.GetBool("activitypub.allow_private_network") {
+		return &http.Client{Timeout: 10 * time.Second}
+	}
+	return newPublicNetworkClient(10 * time.Second)
+}
+
+func IsFederationEnabled() bool {
+	return viper.GetBool("activitypub.enabled")
+}
+
+func GetFederationBaseURL() string {
+	if base := viper.GetString("activitypub.base_url"); len(base) > 0 {
+		return strings.TrimSuffix(base, "/")
+	}
+	return "https://" + viper.GetString("domain")
+}
+
+func GetAccountActorIRI(account models.Account) string {
+	return fmt.Sprintf("%s/ap/users/%s", GetFederationBaseURL(), account.Name)
+}
+
+func GetRealmActorIRI(realm models.Realm) string {
+	return fmt.Sprintf("%s/ap/realms/%s", GetFederationBaseURL(), realm.Alias)
+}
+
+func GetPostIRI(post models.Post) string {
+	if post.FederatedIRI != nil {
+		return *post.FederatedIRI
+	}
+	return fmt.Sprintf("%s/ap/posts/%d", GetFederationBaseURL(), post.ID)
+}
+
+// ParseLocalPostIRI returns the id of the local post the IRI points to.
+func ParseLocalPostIRI(iri string) (uint, bool) {
+	prefix := GetFederationBaseURL() + "/ap/posts/"
+	if !strings.HasPrefix(iri, prefix) {
+		return 0, false
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(iri, prefix))
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func GetActorKey(iri string) (models.ActorKey, error) {
+	var key models.ActorKey
+	if err := database.C.Where("actor_iri = ?", iri).First(&key).Error; err == nil {
+		return key, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return key, err
+	}
+
+	public, private, err := GenerateActorKeyPair()
+	if err != nil {
+		return key, fmt.Errorf("unable to generate actor key: %v", err)
+	}
+	key = models.ActorKey{ActorIRI: iri, PublicKey: public, PrivateKey: private}
+	if err := database.C.Save(&key).Error; err != nil {
+		// Someone else generated it at the same time
+		if err := database.C.Where("actor_iri = ?", iri).First(&key).Error; err != nil {
+			return key, err
+		}
+	}
+
+	return key, nil
+}
+
+func newActorDocument(kind, iri, username, name, summary string) (ActivityObject, error) {
+	key, err := GetActorKey(iri)
+	if err != nil {
+		return nil, err
+	}
+
+	return ActivityObject{
+		"@context":          []string{ActivityStreamsContext, "https://w3id.org/security/v1"},
+		"id":                iri,
+		"type":              kind,
+		"preferredUsername": username,
+		"name":              name,
+		"summary":           summary,
+		"inbox":             iri + "/inbox",
+		"outbox":            iri + "/outbox",
+		"followers":         iri + "/followers",
+		"endpoints": ActivityObject{
+			"sharedInbox": GetFederationBaseURL() + "/ap/inbox",
+		},
+		"publicKey": ActivityObject{
+			"id":           iri + "#main-key",
+			"owner":        iri,
+			"publicKeyPem": key.PublicKey,
+		},
+	}, nil
+}
+
+func NewAccountActor(account models.Account) (ActivityObject, error) {
+	return newActorDocument("Person", GetAccountActorIRI(account), account.Name, account.Nick, "")
+}
+
+func NewRealmActor(realm models.Realm) (ActivityObject, error) {
+	return newActorDocument("Group", GetRealmActorIRI(realm), realm.Alias, realm.Name, realm.Description)
+}
+
+func NewPostObject(post models.Post) ActivityObject {
+	EnsurePostRendered(&post)
+
+	actor := GetAccountActorIRI(post.Author)
+	object := ActivityObject{
+		"id":           GetPostIRI(post),
+		"type":         "Note",
+		"attributedTo": actor,
+		"content":      post.RenderedContent,
+		"to":           []string{ActivityStreamsPublic},
+		"cc":           []string{actor + "/followers"},
+	}
+	if post.Type == models.PostTypeArticle {
+		object["type"] = "Article"
+	}
+	if title, ok := post.Body["title"].(string); ok && len(title) > 0 {
+		object["name"] = title
+	}
+	if description, ok := post.Body["description"].(string); ok && len(description) > 0 {
+		object["summary"] = description
+	}
+	if post.PublishedAt != nil {
+		object["published"] = post.PublishedAt.UTC().Format(time.RFC3339)
+	}
+	if post.EditedAt != nil {
+		object["updated"] = post.EditedAt.UTC().Format(time.RFC3339)
+	}
+	if post.ReplyTo != nil {
+		object["inReplyTo"] = GetPostIRI(*post.ReplyTo)
+	}
+	if post.Realm != nil {
+		object["audience"] = GetRealmActorIRI(*post.Realm)
+	}
+	if len(post.Tags) > 0 {
+		object["tag"] = lo.Map(post.Tags, func(item models.Tag, index int) ActivityObject {
+			return ActivityObject{"type": "Hashtag", "name": "#" + item.Alias}
+		})
+	}
+
+	return object
+}
+
+// NewPostActivity wraps the post into the activity shown in the outbox,
+// the reposts are announces of the original posts.
+func NewPostActivity(post models.Post) ActivityObject {
+	actor := GetAccountActorIRI(post.Author)
+	activity := ActivityObject{
+		"id":    GetPostIRI(post) + "/activity",
+		"actor": actor,
+		"to":    []string{ActivityStreamsPublic},
+		"cc":    []string{actor + "/followers"},
+	}
+	if post.PublishedAt != nil {
+		activity["published"] = post.PublishedAt.UTC().Format(time.RFC3339)
+	}
+
+	if post.RepostTo != nil {
+		activity["type"] = "Announce"
+		activity["object"] = GetPostIRI(*post.RepostTo)
+	} else {
+		activity["type"] = "Create"
+		activity["object"] = NewPostObject(post)
+	}
+
+	return activity
+}
+
+func CountFederatedPosts(tx *gorm.DB) (int64, error) {
+	var count int64
+	err := FilterPostPublic(tx).Model(&models.Post{}).Count(&count).Error
+	return count, err
+}
+
+func ListFederatedPosts(tx *gorm.DB, take, offset int) ([]*models.Post, error) {
+	return ListPostMinimal(PreloadGeneral(FilterPostPublic(tx)), take, offset, "published_at DESC")
+}
+
+func activityObjectIRI(val any) string {
+	switch v := val.(type) {
+	case string:
+		return v
+	case map[string]any:
+		id, _ := v["id"].(string)
+		return id
+	}
+	return ""
+}
+
+func fetchActivityObject(ctx context.Context, iri string) (ActivityObject, error) {
+	if u, err := url.Parse(iri); err != nil || (u.Scheme != "https" && !viper.GetBool("activitypub.allow_private_network")) {
+		return nil, fmt.Errorf("invalid activity object iri %s", iri)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, iri, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", ActivityContentType)
+	req.Header.Set("User-Agent", "Hydrogen.Interactive ActivityPub")
+
+	resp, err := getFederationClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	var object ActivityObject
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, activityBodyLimit))
+	if err != nil {
+		return nil, err
+	}
+	if err := jsoniter.Unmarshal(raw, &object); err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+// GetRemoteActor gets the remote actor by its IRI and maps it into a local account,
+// the actor document is fetched again when it is older than the ttl or refresh is set.
+func GetRemoteActor(iri string, refresh bool) (models.RemoteActor, error) {
+	var actor models.RemoteActor
+	err := database.C.Where("iri = ?", iri).Preload("Account").First(&actor).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return actor, err
+	}
+	if err == nil && !refresh && time.Since(actor.FetchedAt) < viper.GetDuration("activitypub.actor_ttl") {
+		return actor, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	doc, err := fetchActivityObject(ctx, iri)
+	if err != nil {
+		return actor, fmt.Errorf("unable to fetch remote actor: %v", err)
+	}
+	if activityObjectIRI(doc) != iri {
+		return actor, fmt.Errorf("remote actor id mismatch")
+	}
+
+	publicKey, _ := doc["publicKey"].(map[string]any)
+	inbox, _ := doc["inbox"].(string)
+	username, _ := doc["preferredUsername"].(string)
+	nick, _ := doc["name"].(string)
+	if publicKey == nil || len(inbox) == 0 || len(username) == 0 {
+		return actor, fmt.Errorf("remote actor is missing inbox, username or public key")
+	}
+	if len(nick) == 0 {
+		nick = username
+	}
+	u, _ := url.Parse(iri)
+
+	actor.IRI = iri
+	actor.Inbox = inbox
+	actor.PreferredUsername = username
+	actor.Domain = u.Host
+	actor.PublicKeyID, _ = publicKey["id"].(string)
+	actor.PublicKey, _ = publicKey["publicKeyPem"].(string)
+	actor.FetchedAt = time.Now()
+	if endpoints, ok := doc["endpoints"].(map[string]any); ok {
+		if shared, ok := endpoints["sharedInbox"].(string); ok && len(shared) > 0 {
+			actor.SharedInbox = &shared
+		}
+	}
+
+	err = database.C.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Account").Save(&actor).Error; err != nil {
+			return err
+		}
+		actor.Account.ID = RemoteAccountIDBase + actor.ID
+		actor.Account.Name = fmt.Sprintf("%s@%s", username, actor.Domain)
+		actor.Account.Nick = nick
+		if err := tx.Save(&actor.Account).Error; err != nil {
+			return err
+		}
+		actor.AccountID = actor.Account.ID
+		return tx.Model(&actor).Update("account_id", actor.AccountID).Error
+	})
+
+	return actor, err
+}
+
+// VerifyActivityRequest verifies the http signature of an inbox request and returns the signer.
+func VerifyActivityRequest(method, target string, get func(string) string, body []byte) (models.RemoteActor, error) {
+	var actor models.RemoteActor
+
+	sign, err := parseHTTPSignature(get("signature"))
+	if err != nil {
+		return actor, err
+	}
+
+	iri, _, _ := strings.Cut(sign.KeyID, "#")
+	if err := database.C.Where("public_key_id = ?", sign.KeyID).Preload("Account").First(&actor).Error; err != nil {
+		if actor, err = GetRemoteActor(iri, false); err != nil {
+			return actor, err
+		}
+	}
+
+	if err := verifyHTTPSignature(sign, method, target, get, body, actor.PublicKey); err != nil {
+		// The key may be rotated, fetch the actor again and give it another try
+		if actor, err = GetRemoteActor(actor.IRI, true); err != nil {
+			return actor, err
+		}
+		if err := verifyHTTPSignature(sign, method, target, get, body, actor.PublicKey); err != nil {
+			return actor, fmt.Errorf("invalid signature: %v", err)
+		}
+	}
+	if actor.PublicKeyID != sign.KeyID {
+		return actor, fmt.Errorf("signature key does not belong to the actor")
+	}
+
+	return actor, nil
+}
+
+func CountRemoteFollowers(iri string) (int64, error) {
+	var count int64
+	err := database.C.Model(&models.RemoteFollower{}).Where("target_iri = ?", iri).Count(&count).Error
+	return count, err
+}
+
+// ResolveWebFinger returns the webfinger document of a resource like acct:name@domain.
+func ResolveWebFinger(resource string) (ActivityObject, error) {
+	handle, ok := strings.CutPrefix(resource, "acct:")
+	if !ok {
+		return nil, fmt.Errorf("unsupported resource %s", resource)
+	}
+	name, domain, _ := strings.Cut(strings.TrimPrefix(handle, "@"), "@")
+	if base, _ := url.Parse(GetFederationBaseURL()); base == nil || !strings.EqualFold(domain, base.Host) {
+		return nil, fmt.Errorf("resource does not belong to this server")
+	}
+
+	var iri string
+	if account, err := GetLocalAccountWithName(name); err == nil {
+		iri = GetAccountActorIRI(account)
+	} else if realm, err := GetPublicRealmWithAlias(context.Background(), name); err == nil {
+		iri = GetRealmActorIRI(realm)
+	} else {
+		return nil, fmt.Errorf("resource was not found")
+	}
+
+	return ActivityObject{
+		"subject": resource,
+		"aliases": []string{iri},
+		"links": []ActivityObject{
+			{"rel": "self", "type": ActivityContentType, "href": iri},
+		},
+	}, nil
+}
+
+// resolveLocalActor finds the local account or realm by its actor IRI.
+func resolveLocalActor(iri string) (string, bool) {
+	base := GetFederationBaseURL()
+	if name, ok := strings.CutPrefix(iri, base+"/ap/users/"); ok {
+		if account, err := GetLocalAccountWithName(name); err == nil {
+			return GetAccountActorIRI(account), true
+		}
+	} else if alias, ok := strings.CutPrefix(iri, base+"/ap/realms/"); ok {
+		if realm, err := GetPublicRealmWithAlias(context.Background(), alias); err == nil {
+			return GetRealmActorIRI(realm), true
+		}
+	}
+	return "", false
+}
+
+// HandleInboxActivity applies the activity sent by a remote actor.
+func HandleInboxActivity(actor models.RemoteActor, activity ActivityObject) error {
+	if activityObjectIRI(activity["actor"]) != actor.IRI {
+		return fmt.Errorf("activity actor does not match the signer")
+	}
+
+	kind, _ := activity["type"].(string)
+	switch kind {
+	case "Follow":
+		return handleRemoteFollow(actor, activity)
+	case "Undo":
+		object, _ := activity["object"].(map[string]any)
+		switch objectKind, _ := object["type"].(string); objectKind {
+		case "Follow":
+			target, ok := resolveLocalActor(activityObjectIRI(object["object"]))
+			if !ok {
+				return fmt.Errorf("follow target was not found")
+			}
+			return database.C.Unscoped().
+				Where("actor_id = ? AND target_iri = ?", actor.ID, target).
+				Delete(&models.RemoteFollower{}).Error
+		case "Like":
+			return handleRemoteLike(actor, activityObjectIRI(object["object"]), false)
+		}
+	case "Like":
+		return handleRemoteLike(actor, activityObjectIRI(activity["object"]), true)
+	case "Create":
+		object, _ := activity["object"].(map[string]any)
+		return handleRemoteReply(actor, object)
+	case "Delete":
+		iri := activityObjectIRI(activity["object"])
+		return database.C.
+			Where("federated_iri = ? AND author_id = ?", iri, actor.AccountID).
+			Delete(&models.Post{}).Error
+	}
+
+	log.Debug().Str("type", kind).Str("actor", actor.IRI).Msg("Ignored unsupported activity...")
+	return nil
+}
+
+func handleRemoteFollow(actor models.RemoteActor, activity ActivityObject) error {
+	target, ok := resolveLocalActor(activityObjectIRI(activity["object"]))
+	if !ok {
+		return fmt.Errorf("follow target was not found")
+	}
+
+	follower := models.RemoteFollower{ActorID: actor.ID, TargetIRI: target}
+	if err := database.C.
+		Where("actor_id = ? AND target_iri = ?", actor.ID, target).
+		FirstOrCreate(&follower).Error; err != nil {
+		return err
+	}
+
+	accept := ActivityObject{
+		"@context": ActivityStreamsContext,
+		"id":       fmt.Sprintf("%s#accepts/%d", target, follower.ID),
+		"type":     "Accept",
+		"actor":    target,
+		"object":   activity,
+	}
+	go func() {
+		if err := DeliverActivity(target, actor.Inbox, accept); err != nil {
+			log.Warn().Err(err).Str("actor", actor.IRI).Msg("Unable to deliver follow accept...")
+		}
+	}()
+
+	return nil
+}
+
+func handleRemoteLike(actor models.RemoteActor, iri string, isLike bool) error {
+	id, ok := ParseLocalPostIRI(iri)
+	if !ok {
+		return fmt.Errorf("liked post was not found")
+	}
+	post, err := GetPost(FilterPostPublic(database.C), id)
+	if err != nil {
+		return fmt.Errorf("liked post was not found: %v", err)
+	}
+
+	reaction := models.Reaction{
+		Symbol:    "thumb_up",
+		Attitude:  models.AttitudePositive,
+		PostID:    &post.ID,
+		AccountID: actor.AccountID,
+	}
+	var count int64
+	if err := database.C.Model(&models.Reaction{}).Where(reaction).Count(&count).Error; err != nil {
+		return err
+	}
+
+	// ReactPost toggles the reaction, only call it when the state changes
+	if (count > 0) != isLike {
+		_, _, err = ReactPost(actor.Account, reaction)
+	}
+	return err
+}
+
+func handleRemoteReply(actor models.RemoteActor, object ActivityObject) error {
+	iri := activityObjectIRI(object)
+	replyTo, ok := ParseLocalPostIRI(activityObjectIRI(object["inReplyTo"]))
+	if len(iri) == 0 || !ok {
+		// Only the replies to the local posts are accepted, there is no timeline of remote posts
+		return nil
+	}
+	if attributedTo := activityObjectIRI(object["attributedTo"]); attributedTo != actor.IRI {
+		return fmt.Errorf("object is not attributed to the actor")
+	}
+
+	op, err := GetPost(FilterPostPublic(database.C), replyTo)
+	if err != nil {
+		return fmt.Errorf("replied post was not found: %v", err)
+	}
+
+	var count int64
+	if err := database.C.Model(&models.Post{}).Where("federated_iri = ?", iri).Count(&count).Error; err != nil {
+		return err
+	} else if count > 0 {
+		return nil
+	}
+
+	raw, _ := object["content"].(string)
+	content := html.UnescapeString(plaintextPolicy.Sanitize(raw))
+	kind, _ := GetPostType(models.PostTypeStory)
+	item := models.Post{
+		Type:         models.PostTypeStory,
+		Body:         map[string]any{"content": content},
+		ReplyID:      &op.ID,
+		AuthorID:     actor.AccountID,
+		Visibility:   models.PostVisibilityAll,
+		PublishedAt:  lo.ToPtr(time.Now()),
+		FederatedIRI: &iri,
+	}
+	item.Language, item.LanguageConfidence = DetectPostLanguage(kind, item.Body)
+
+	_, err = NewPost(actor.Account, item)
+	return err
+}
+
+// DeliverActivity signs and sends the activity to a remote inbox as the local actor.
+func DeliverActivity(actorIRI, inbox string, activity ActivityObject) error {
+	key, err := GetActorKey(actorIRI)
+	if err != nil {
+		return err
+	}
+	if _, ok := activity["@context"]; !ok {
+		activity["@context"] = ActivityStreamsContext
+	}
+	body, err := jsoniter.Marshal(activity)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, inbox, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", ActivityContentType)
+	req.Header.Set("User-Agent", "Hydrogen.Interactive ActivityPub")
+	if err := SignHTTPRequest(req, actorIRI+"#main-key", key.PrivateKey, body); err != nil {
+		return err
+	}
+
+	resp, err := getFederationClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("remote inbox responded %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// FederatePost delivers the new public post to the remote followers of the author and the realm.
+// The scheduled posts are skipped here, DoScheduledPostFederation delivers them once published.
+func FederatePost(post models.Post) {
+	if !IsFederationEnabled() || post.IsDraft || post.Visibility != models.PostVisibilityAll || post.FederatedIRI != nil {
+		return
+	}
+	if post.PublishedAt != nil && post.PublishedAt.After(time.Now()) {
+		return
+	}
+
+	// Claim the post first, so it won't be delivered twice by the scheduled job
+	if tx := database.C.Model(&models.Post{}).
+		Where("id = ? AND federated_at IS NULL", post.ID).
+		UpdateColumn("federated_at", time.Now()); tx.Error != nil {
+		log.Error().Err(tx.Error).Uint("post", post.ID).Msg("Unable to mark post as federated...")
+		return
+	} else if tx.RowsAffected == 0 {
+		return
+	}
+
+	if post.ReplyID != nil && post.ReplyTo == nil {
+		var replyTo models.Post
+		if err := database.C.Where("id = ?", post.ReplyID).First(&replyTo).Error; err == nil {
+			post.ReplyTo = &replyTo
+		}
+	}
+	if post.RepostID != nil && post.RepostTo == nil {
+		var repostTo models.Post
+		if err := database.C.Where("id = ?", post.RepostID).First(&repostTo).Error; err == nil {
+			post.RepostTo = &repostTo
+		}
+	}
+
+	actor := GetAccountActorIRI(post.Author)
+	targets := []string{actor}
+	if post.Realm != nil {
+		targets = append(targets, GetRealmActorIRI(*post.Realm))
+	}
+
+	var followers []models.RemoteFollower
+	if err := database.C.
+		Where("target_iri IN ?", targets).
+		Preload("Actor").
+		Find(&followers).Error; err != nil {
+		log.Error().Err(err).Uint("post", post.ID).Msg("Unable to get remote followers...")
+		return
+	}
+
+	inboxes := lo.Uniq(lo.Map(followers, func(item models.RemoteFollower, index int) string {
+		if item.Actor.SharedInbox != nil {
+			return *item.Actor.SharedInbox
+		}
+		return item.Actor.Inbox
+	}))
+
+	activity := NewPostActivity(post)
+	for _, inbox := range inboxes {
+		if err := DeliverActivity(actor, inbox, activity); err != nil {
+			log.Warn().Err(err).Str("inbox", inbox).Uint("post", post.ID).Msg("Unable to deliver post to remote inbox...")
+		}
+	}
+}
+
+// DoScheduledPostFederation federates the scheduled posts reached their published time.
+// Only the posts published in the last day are picked, the older ones were missed for too long.
+func DoScheduledPostFederation() {
+	if !IsFederationEnabled() {
+		return
+	}
+
+	now := time.Now()
+	var items []models.Post
+	if err := database.C.
+		Where("federated_at IS NULL AND federated_iri IS NULL").
+		Where("is_draft = ? AND visibility = ?", false, models.PostVisibilityAll).
+		Where("published_at <= ? AND published_at > ?", now, now.Add(-24*time.Hour)).
+		// The posts published right away have the published time before the created time
+		Where("published_at > created_at").
+		Preload("Author").
+		Preload("Realm").
+		Find(&items).Error; err != nil {
+		log.Error().Err(err).Msg("An error occurred when getting scheduled posts to federate...")
+		return
+	}
+
+	for _, item := range items {
+		FederatePost(item)
+	}
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/samber/lo"
+	"github.com/spf13/viper"
+	"gorm.io/datatypes"
+)
+
+type receivedActivity struct {
+	activity ActivityObject
+	err      error
+}
+
+// fakeRemoteActor serves the actor document of bob on a fake remote server,
+// and checks the signature of the activities delivered to the inbox of bob.
+func fakeRemoteActor(t *testing.T, publicKey string, signerKey func() string) (*httptest.Server, chan receivedActivity) {
+	t.Helper()
+
+	received := make(chan receivedActivity, 8)
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		iri := server.URL + "/users/bob"
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/users/bob":
+			w.Header().Set("Content-Type", ActivityContentType)
+			_ = jsoniter.NewEncoder(w).Encode(ActivityObject{
+				"@context":          ActivityStreamsContext,
+				"id":                iri,
+				"type":              "Person",
+				"preferredUsername": "bob",
+				"inbox":             iri + "/inbox",
+				"publicKey": ActivityObject{
+					"id":           iri + "#main-key",
+					"owner":        iri,
+					"publicKeyPem": publicKey,
+				},
+			})
+		case r.Method == http.MethodPost && r.URL.Path == "/users/bob/inbox":
+			body, _ := io.ReadAll(r.Body)
+			var item receivedActivity
+			if sign, err := parseHTTPSignature(r.Header.Get("Signature")); err != nil {
+				item.err = err
+			} else {
+				item.err = verifyHTTPSignature(sign, r.Method, r.URL.RequestURI(), requestHeaderGetter(r), body, signerKey())
+			}
+			if item.err == nil {
+				item.err = jsoniter.Unmarshal(body, &item.activity)
+			}
+			received <- item
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, received
+}
+
+func waitActivity(t *testing.T, received chan receivedActivity, kind string) ActivityObject {
+	t.Helper()
+	select {
+	case item := <-received:
+		if item.err != nil {
+			t.Fatalf("invalid delivered activity: %v", item.err)
+		}
+		if item.activity["type"] != kind {
+			t.Fatalf("delivered activity type = %v, want %s", item.activity["type"], kind)
+		}
+		return item.activity
+	case <-time.After(5 * time.Second):
+		t.Fatalf("activity %s was not delivered", kind)
+	}
+	return nil
+}
+
+func TestInboxFollowAndUndo(t *testing.T) {
+	requireDatabase(t)
+
+	for key, val := range map[string]any{
+		"activitypub.enabled":               true,
+		"activitypub.base_url":              "https://interactive.test",
+		"activitypub.allow_private_network": true,
+		"activitypub.actor_ttl":             "24h",
+	} {
+		key, prev := key, viper.Get(key)
+		viper.Set(key, val)
+		t.Cleanup(func() { viper.Set(key, prev) })
+	}
+	// The client is cached with the private network check of the default settings
+	getFederationClient = newFederationClient
+	t.Cleanup(func() { getFederationClient = sync.OnceValue(newFederationClient) })
+
+	alice := createTestAccount(t, "alice")
+	aliceIRI := GetAccountActorIRI(alice)
+	aliceKey, err := GetActorKey(aliceIRI)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	public, private, err := GenerateActorKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote, received := fakeRemoteActor(t, public, func() string { return aliceKey.PublicKey })
+	bobIRI := remote.URL + "/users/bob"
+
+	send := func(activity ActivityObject) {
+		t.Helper()
+		body, err := jsoniter.Marshal(activity)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := http.NewRequest(http.MethodPost, aliceIRI+"/inbox", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := SignHTTPRequest(req, bobIRI+"#main-key", private, body); err != nil {
+			t.Fatal(err)
+		}
+		actor, err := VerifyActivityRequest(req.Method, req.URL.RequestURI(), requestHeaderGetter(req), body)
+		if err != nil {
+			t.Fatalf("unable to verify inbox request: %v", err)
+		}
+		if actor.AccountID < RemoteAccountIDBase {
+			t.Errorf("remote actor is mapped to local account %d", actor.AccountID)
+		}
+		if err := HandleInboxActivity(actor, activity); err != nil {
+			t.Fatalf("unable to handle %v: %v", activity["type"], err)
+		}
+	}
+	countFollowers := func() int64 {
+		t.Helper()
+		count, err := CountRemoteFollowers(aliceIRI)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return count
+	}
+
+	follow := ActivityObject{
+		"id":     bobIRI + "#follows/1",
+		"type":   "Follow",
+		"actor":  bobIRI,
+		"object": aliceIRI,
+	}
+	send(follow)
+	if count := countFollowers(); count != 1 {
+		t.Fatalf("remote followers = %d, want 1", count)
+	}
+	accept := waitActivity(t, received, "Accept")
+	if accept["actor"] != aliceIRI {
+		t.Errorf("accept actor = %v, want %s", accept["actor"], aliceIRI)
+	}
+
+	// The scheduled post is only delivered to the follower after it was published
+	post := models.Post{
+		Type:        models.PostTypeStory,
+		Body:        datatypes.JSONMap{"content": "Scheduled hello"},
+		Visibility:  models.PostVisibilityAll,
+		PublishedAt: lo.ToPtr(time.Now().Add(time.Hour)),
+		AuthorID:    alice.ID,
+		Author:      alice,
+	}
+	if err := database.C.Omit("Author").Create(&post).Error; err != nil {
+		t.Fatal(err)
+	}
+	FederatePost(post)
+	select {
+	case item := <-received:
+		t.Fatalf("scheduled post was delivered before published: %v", item.activity)
+	case <-time.After(200 * time.Millisecond):
+	}
+	if err := database.C.Model(&post).UpdateColumn("published_at", time.Now()).Error; err != nil {
+		t.Fatal(err)
+	}
+	DoScheduledPostFederation()
+	waitActivity(t, received, "Create")
+	if err := database.C.First(&post, post.ID).Error; err != nil {
+		t.Fatal(err)
+	} else if post.FederatedAt == nil {
+		t.Errorf("published post is not marked as federated")
+	}
+
+	send(ActivityObject{
+		"id":     bobIRI + "#follows/1/undo",
+		"type":   "Undo",
+		"actor":  bobIRI,
+		"object": follow,
+	})
+	if count := countFollowers(); count != 0 {
+		t.Fatalf("remote followers = %d after undo, want 0", count)
+	}
+}
@@ -0,0 +1,349 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	"github.com/samber/lo"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+const (
+	analyticsDateFormat = "2006-01-02"
+	analyticsDateKey    = "TO_CHAR(DATE(created_at AT TIME ZONE 'UTC'), 'YYYY-MM-DD')"
+)
+
+type AnalyticsStats struct {
+	Views       int64 `json:"views"`
+	Reactions   int64 `json:"reactions"`
+	Replies     int64 `json:"replies"`
+	Subscribers int64 `json:"subscribers"`
+}
+
+type AnalyticsDay struct {
+	AnalyticsStats
+	Date string `json:"date"`
+}
+
+type AnalyticsPost struct {
+	Views     int64 `json:"views"`
+	Reactions int64 `json:"reactions"`
+	Replies   int64 `json:"replies"`
+	PostID    uint  `json:"post_id"`
+}
+
+type Analytics struct {
+	Since time.Time       `json:"since"`
+	Total AnalyticsStats  `json:"total"`
+	Days  []AnalyticsDay  `json:"days"`
+	Posts []AnalyticsPost `json:"posts"`
+}
+
+type analyticsRow struct {
+	Bucket string
+	Count  int64
+}
+
+func scanAnalyticsRows(tx *gorm.DB, key, aggregate string) (map[string]int64, error) {
+	var rows []analyticsRow
+	if err := tx.
+		Select(key + " AS bucket, " + aggregate + " AS count").
+		Group("bucket").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	return lo.SliceToMap(rows, func(item analyticsRow) (string, int64) {
+		return item.Bucket, item.Count
+	}), nil
+}
+
+// GetPostAnalytics sums up the views, reactions and replies of the posts and the new subscribers
+// day by day since the date. The posts is a subquery selecting the post ids and the subscriptions
+// is a query of the subscriptions to count.
+func GetPostAnalytics(posts *gorm.DB, subscriptions *gorm.DB, since time.Time, take int) (Analytics, error) {
+	since = since.UTC().Truncate(24 * time.Hour)
+	out := Analytics{Since: since}
+
+	views := func() *gorm.DB {
+		return database.C.Model(&models.PostDailyView{}).Where("post_id IN (?) AND date >= ?", posts, since)
+	}
+	reactions := func() *gorm.DB {
+		return database.C.Model(&models.Reaction{}).Where("post_id IN (?) AND created_at >= ?", posts, since)
+	}
+	replies := func() *gorm.DB {
+		return database.C.Model(&models.Post{}).Where("reply_id IN (?) AND created_at >= ?", posts, since)
+	}
+
+	dailyViews, err := scanAnalyticsRows(views(), "TO_CHAR(date, 'YYYY-MM-DD')", "SUM(count)")
+	if err != nil {
+		return out, err
+	}
+	dailyReactions, err := scanAnalyticsRows(reactions(), analyticsDateKey, "COUNT(*)")
+	if err != nil {
+		return out, err
+	}
+	dailyReplies, err := scanAnalyticsRows(replies(), analyticsDateKey, "COUNT(*)")
+	if err != nil {
+		return out, err
+	}
+	dailySubscribers, err := scanAnalyticsRows(subscriptions.Where("created_at >= ?", since), analyticsDateKey, "COUNT(*)")
+	if err != nil {
+		return out, err
+	}
+
+	for date := since; !date.After(time.Now().UTC()); date = date.AddDate(0, 0, 1) {
+		key := date.Format(analyticsDateFormat)
+		day := AnalyticsDay{
+			AnalyticsStats: AnalyticsStats{
+				Views:       dailyViews[key],
+				Reactions:   dailyReactions[key],
+				Replies:     dailyReplies[key],
+				Subscribers: dailySubscribers[key],
+			},
+			Date: key,
+		}
+		out.Total.Views += day.Views
+		out.Total.Reactions += day.Reactions
+		out.Total.Replies += day.Replies
+		out.Total.Subscribers += day.Subscribers
+		out.Days = append(out.Days, day)
+	}
+
+	postViews, err := scanAnalyticsRows(views(), "post_id", "SUM(count)")
+	if err != nil {
+		return out, err
+	}
+	postReactions, err := scanAnalyticsRows(reactions(), "post_id", "COUNT(*)")
+	if err != nil {
+		return out, err
+	}
+	postReplies, err := scanAnalyticsRows(replies(), "reply_id", "COUNT(*)")
+	if err != nil {
+		return out, err
+	}
+
+	keys := lo.Uniq(append(append(lo.Keys(postViews), lo.Keys(postReactions)...), lo.Keys(postReplies)...))
+	for _, key := range keys {
+		id, err := strconv.Atoi(key)
+		if err != nil {
+			continue
+		}
+		out.Posts = append(out.Posts, AnalyticsPost{
+			Views:     postViews[key],
+			Reactions: postReactions[key],
+			Replies:   postReplies[key],
+			PostID:    uint(id),
+		})
+	}
+	sort.Slice(out.Posts, func(i, j int) bool {
+		if out.Posts[i].Views != out.Posts[j].Views {
+			return out.Posts[i].Views > out.Posts[j].Views
+		}
+		return out.Posts[i].PostID > out.Posts[j].PostID
+	})
+	if take > 0 && len(out.Posts) > take {
+		out.Posts = out.Posts[:take]
+	}
+
+	return out, nil
+}
+
+func GetAccountAnalytics(user models.Account, since time.Time, take int) (Analytics, error) {
+	posts := database.C.Model(&models.Post{}).Select("id").Where("author_id = ?", user.ID)
+	subscriptions := database.C.Model(&models.Subscription{}).Where("account_id = ?", user.ID)
+	return GetPostAnalytics(posts, subscriptions, since, take)
+}
+
+type AnalyticsCount struct {
+	Date  string `json:"date"`
+	Count int64  `json:"count"`
+}
+
+func fillAnalyticsDays(since time.Time, counts map[string]int64) []AnalyticsCount {
+	var out []AnalyticsCount
+	for date := since.UTC().Truncate(24 * time.Hour); !date.After(time.Now().UTC()); date = date.AddDate(0, 0, 1) {
+		key := date.Format(analyticsDateFormat)
+		out = append(out, AnalyticsCount{Date: key, Count: counts[key]})
+	}
+	return out
+}
+
+// EnsureRealmAnalyticsPerm checks the user is allowed to see the analytics of the realm.
+func EnsureRealmAnalyticsPerm(ctx context.Context, realm models.Realm, user models.Account) error {
+	return EnsureRealmPowerLevel(ctx, realm.ID, user, viper.GetInt32("analytics.realm_power_level"), "see its analytics")
+}
+
+func realmAnalyticsPosts(realm models.Realm) *gorm.DB {
+	return FilterPostDraft(database.C.Model(&models.Post{}).Where("realm_id = ?", realm.ID))
+}
+
+func ListRealmDailyPosts(realm models.Realm, since time.Time) ([]AnalyticsCount, error) {
+	counts, err := scanAnalyticsRows(
+		realmAnalyticsPosts(realm).Where("created_at >= ?", since),
+		analyticsDateKey,
+		"COUNT(*)",
+	)
+	if err != nil {
+		return nil, err
+	}
+	return fillAnalyticsDays(since, counts), nil
+}
+
+type AnalyticsPoster struct {
+	AccountID uint           `json:"account_id"`
+	Account   models.Account `json:"account" gorm:"-"`
+	Posts     int64          `json:"posts"`
+	Replies   int64          `json:"replies"`
+}
+
+func ListRealmActivePosters(realm models.Realm, since time.Time, take int) ([]AnalyticsPoster, error) {
+	var posters []AnalyticsPoster
+	if err := realmAnalyticsPosts(realm).
+		Select("author_id AS account_id, COUNT(*) FILTER (WHERE reply_id IS NULL) AS posts, COUNT(*) FILTER (WHERE reply_id IS NOT NULL) AS replies").
+		Where("created_at >= ?", since).
+		Group("author_id").
+		Order("COUNT(*) DESC").
+		Limit(take).
+		Scan(&posters).Error; err != nil {
+		return posters, err
+	}
+
+	var accounts []models.Account
+	if err := database.C.
+		Where("id IN ?", lo.Map(posters, func(item AnalyticsPoster, index int) uint { return item.AccountID })).
+		Find(&accounts).Error; err != nil {
+		return posters, err
+	}
+	accountMap := lo.SliceToMap(accounts, func(item models.Account) (uint, models.Account) {
+		return item.ID, item
+	})
+	for idx := range posters {
+		posters[idx].Account = accountMap[posters[idx].AccountID]
+	}
+
+	return posters, nil
+}
+
+// ListRealmTopPosts ranks the posts of the realm by the views, reactions or replies they got since the date.
+func ListRealmTopPosts(realm models.Realm, since time.Time, by string, take int) ([]AnalyticsPost, error) {
+	posts := realmAnalyticsPosts(realm).Select("id")
+	subscriptions := database.C.Model(&models.Subscription{}).Where("realm_id = ?", realm.ID)
+
+	out, err := GetPostAnalytics(posts, subscriptions, since, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	score := func(item AnalyticsPost) int64 {
+		switch by {
+		case "reactions":
+			return item.Reactions
+		case "replies":
+			return item.Replies
+		}
+		return item.Views
+	}
+	sort.SliceStable(out.Posts, func(i, j int) bool {
+		return score(out.Posts[i]) > score(out.Posts[j])
+	})
+	if take > 0 && len(out.Posts) > take {
+		out.Posts = out.Posts[:take]
+	}
+
+	return out.Posts, nil
+}
+
+type AnalyticsTag struct {
+	Alias string `json:"alias"`
+	Name  string `json:"name"`
+	Posts int64  `json:"posts"`
+}
+
+func ListRealmTopTags(realm models.Realm, since time.Time, take int) ([]AnalyticsTag, error) {
+	prefix := viper.GetString("database.prefix")
+
+	var tags []AnalyticsTag
+	if err := database.C.Model(&models.Tag{}).
+		Select(fmt.Sprintf("%stags.alias, %stags.name, COUNT(*) AS posts", prefix, prefix)).
+		Joins(fmt.Sprintf("JOIN %spost_tags ON %spost_tags.tag_id = %stags.id", prefix, prefix, prefix)).
+		Where(
+			fmt.Sprintf("%spost_tags.post_id IN (?)", prefix),
+			realmAnalyticsPosts(realm).Select("id").Where("created_at >= ?", since),
+		).
+		Group(fmt.Sprintf("%stags.id", prefix)).
+		Order("posts DESC").
+		Limit(take).
+		Scan(&tags).Error; err != nil {
+		return tags, err
+	}
+
+	return tags, nil
+}
+
+type AnalyticsReactions struct {
+	Symbols   map[string]int64 `json:"symbols"`
+	Positive  int64            `json:"positive"`
+	Neutral   int64            `json:"neutral"`
+	Negative  int64            `json:"negative"`
+	Reactions int64            `json:"reactions"`
+}
+
+func GetRealmReactionDistribution(realm models.Realm, since time.Time) (AnalyticsReactions, error) {
+	out := AnalyticsReactions{Symbols: map[string]int64{}}
+
+	reactions := func() *gorm.DB {
+		return database.C.Model(&models.Reaction{}).
+			Where("post_id IN (?) AND created_at >= ?", realmAnalyticsPosts(realm).Select("id"), since)
+	}
+
+	var err error
+	if out.Symbols, err = scanAnalyticsRows(reactions(), "symbol", "COUNT(*)"); err != nil {
+		return out, err
+	}
+
+	attitudes, err := scanAnalyticsRows(reactions(), "attitude::text", "COUNT(*)")
+	if err != nil {
+		return out, err
+	}
+	out.Neutral = attitudes[strconv.Itoa(int(models.AttitudeNeutral))]
+	out.Positive = attitudes[strconv.Itoa(int(models.AttitudePositive))]
+	out.Negative = attitudes[strconv.Itoa(int(models.AttitudeNegative))]
+	out.Reactions = out.Neutral + out.Positive + out.Negative
+
+	return out, nil
+}
+
+type AnalyticsSubscribers struct {
+	Total int64            `json:"total"`
+	Days  []AnalyticsCount `json:"days"`
+}
+
+func GetRealmSubscriberGrowth(realm models.Realm, since time.Time) (AnalyticsSubscribers, error) {
+	var out AnalyticsSubscribers
+
+	subscriptions := func() *gorm.DB {
+		return database.C.Model(&models.Subscription{}).Where("realm_id = ?", realm.ID)
+	}
+
+	if err := subscriptions().Count(&out.Total).Error; err != nil {
+		return out, err
+	}
+	counts, err := scanAnalyticsRows(
+		subscriptions().Where("created_at >= ?", since),
+		analyticsDateKey,
+		"COUNT(*)",
+	)
+	if err != nil {
+		return out, err
+	}
+	out.Days = fillAnalyticsDays(since, counts)
+
+	return out, nil
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"math"
+	"unicode"
+
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+const (
+	ReadingSpeedWords = 200
+	ReadingSpeedCJK   = 400
+)
+
+// CountWords counts latin words by whitespace and punctuation, and counts each
+// CJK character as a word, because those languages have no word separators.
+func CountWords(content string) (int, int) {
+	var words, cjk int
+	inWord := false
+	for _, r := range content {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			cjk++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				words++
+			}
+			inWord = true
+		case inWord && (r == '\'' || r == '’'):
+			continue
+		default:
+			inWord = false
+		}
+	}
+
+	return words, cjk
+}
+
+func EstimateReadingTime(words, cjk int) int {
+	if words+cjk == 0 {
+		return 0
+	}
+	minutes := float64(words)/ReadingSpeedWords + float64(cjk)/ReadingSpeedCJK
+	return max(1, int(math.Ceil(minutes)))
+}
+
+func ListArticleHeadings(content string) []models.PostArticleHeading {
+	source := []byte(content)
+	document := markdown.Parser().Parse(text.NewReader(source))
+
+	var headings []models.PostArticleHeading
+	_ = ast.Walk(document, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		heading, ok := node.(*ast.Heading)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+
+		var anchor string
+		if id, ok := heading.AttributeString("id"); ok {
+			if val, ok := id.([]byte); ok {
+				anchor = string(val)
+			}
+		}
+
+		headings = append(headings, models.PostArticleHeading{
+			Level:  heading.Level,
+			Title:  string(heading.Text(source)),
+			Anchor: anchor,
+		})
+
+		return ast.WalkSkipChildren, nil
+	})
+
+	return headings
+}
+
+func AnalyzeArticle(item models.Post) models.Post {
+	content, ok := item.Body["content"].(string)
+	if !ok {
+		return item
+	}
+
+	words, cjk := CountWords(item.PlaintextContent)
+	item.WordCount = words + cjk
+	item.ReadingTime = EstimateReadingTime(words, cjk)
+
+	item.Body["table_of_contents"] = ListArticleHeadings(content)
+	if item.Body["description"] == nil {
+		item.Body["description"] = TruncatePostContentShort(item.PlaintextContent)
+	}
+
+	return item
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"fmt"
+
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+func ListAudienceLists(user models.Account) ([]models.AudienceList, error) {
+	var lists []models.AudienceList
+	if err := database.C.
+		Where("account_id = ?", user.ID).
+		Order("created_at ASC").
+		Find(&lists).Error; err != nil {
+		return lists, err
+	}
+
+	return lists, nil
+}
+
+func GetAudienceListWithUser(id uint, user models.Account) (models.AudienceList, error) {
+	var list models.AudienceList
+	if err := database.C.
+		Where("id = ? AND account_id = ?", id, user.ID).
+		Preload("Members").
+		Preload("Members.Account").
+		First(&list).Error; err != nil {
+		return list, err
+	}
+	return list, nil
+}
+
+// GetAudienceListsWithIDs gets the lists owned by the user, fails if any of them
+// does not exist or belongs to others.
+func GetAudienceListsWithIDs(user models.Account, id []uint) ([]models.AudienceList, error) {
+	var lists []models.AudienceList
+	if len(id) == 0 {
+		return lists, nil
+	}
+
+	if err := database.C.
+		Where("id IN ? AND account_id = ?", id, user.ID).
+		Find(&lists).Error; err != nil {
+		return lists, err
+	} else if len(lists) != len(id) {
+		return lists, fmt.Errorf("some audience lists were not found")
+	}
+
+	return lists, nil
+}
+
+func NewAudienceList(user models.Account, name string) (models.AudienceList, error) {
+	list := models.AudienceList{
+		Name:      name,
+		AccountID: user.ID,
+	}
+
+	err := database.C.Save(&list).Error
+
+	return list, err
+}
+
+func EditAudienceList(list models.AudienceList, name string) (models.AudienceList, error) {
+	list.Name = name
+
+	err := database.C.Omit("Members").Save(&list).Error
+
+	return list, err
+}
+
+func DeleteAudienceList(list models.AudienceList) error {
+	return database.C.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().
+			Where("list_id = ?", list.ID).
+			Delete(&models.AudienceListMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&list).Association("Posts").Clear(); err != nil {
+			return err
+		}
+		return tx.Delete(&list).Error
+	})
+}
+
+func AddAudienceListMember(list models.AudienceList, target models.Account) (models.AudienceListMember, error) {
+	member := models.AudienceListMember{
+		ListID:    list.ID,
+		AccountID: target.ID,
+	}
+	if list.AccountID == target.ID {
+		return member, fmt.Errorf("you are always able to see your own posts")
+	}
+
+	var count int64
+	if err := database.C.Model(&models.AudienceListMember{}).
+		Where("list_id = ? AND account_id = ?", list.ID, target.ID).
+		Count(&count).Error; err != nil {
+		return member, err
+	} else if count > 0 {
+		return member, fmt.Errorf("the user is already in this audience list")
+	}
+
+	err := database.C.Save(&member).Error
+
+	return member, err
+}
+
+func RemoveAudienceListMember(list models.AudienceList, target models.Account) error {
+	tx := database.C.Unscoped().
+		Where("list_id = ? AND account_id = ?", list.ID, target.ID).
+		Delete(&models.AudienceListMember{})
+	if tx.Error != nil {
+		return tx.Error
+	} else if tx.RowsAffected == 0 {
+		return fmt.Errorf("the user is not in this audience list")
+	}
+
+	return nil
+}
+
+// audiencePostsOf is the condition of the posts targeting an audience list containing the user
+func audiencePostsOf(user uint) (string, uint) {
+	prefix := viper.GetString("database.prefix")
+	return fmt.Sprintf(
+		"EXISTS (SELECT 1 FROM %spost_audience_lists JOIN %saudience_list_members ON %saudience_list_members.list_id = %spost_audience_lists.audience_list_id WHERE %spost_audience_lists.post_id = %sposts.id AND %saudience_list_members.account_id = ?)",
+		prefix, prefix, prefix, prefix, prefix, prefix, prefix,
+	), user
+}
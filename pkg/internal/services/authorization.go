@@ -0,0 +1,71 @@
+package services
+
+import (
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+)
+
+const postViewerKey = "interactive:post_viewer"
+
+// FilterPostReadable keeps the posts the user can read, every post read path
+// and interaction should look up the posts through it. The user is nil for
+// anonymous visitors. The posts loaded by GetPost and ListPost from the query
+// get the replied and reposted posts the user cannot read removed.
+func FilterPostReadable(tx *gorm.DB, user *models.Account) *gorm.DB {
+	tx = FilterPostDraft(tx)
+	tx = FilterPostWithUserContext(tx, user)
+	return tx.Set(postViewerKey, user)
+}
+
+// GetReadablePost gets the post when the user can read it, use it before
+// interacting with a post, such as replying, reposting and reacting.
+func GetReadablePost(id uint, user *models.Account) (models.Post, error) {
+	return GetPost(FilterPostReadable(database.C, user), id)
+}
+
+func redactPostRelatives(tx *gorm.DB, items ...*models.Post) error {
+	val, ok := tx.Get(postViewerKey)
+	if !ok {
+		return nil
+	}
+	user, _ := val.(*models.Account)
+
+	var relatives []uint
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+		if item.ReplyTo != nil {
+			relatives = append(relatives, item.ReplyTo.ID)
+		}
+		if item.RepostTo != nil {
+			relatives = append(relatives, item.RepostTo.ID)
+		}
+	}
+	if len(relatives) == 0 {
+		return nil
+	}
+
+	var readable []uint
+	if err := FilterPostReadable(database.C.Model(&models.Post{}), user).
+		Where("id IN ?", lo.Uniq(relatives)).
+		Pluck("id", &readable).Error; err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+		if item.ReplyTo != nil && !lo.Contains(readable, item.ReplyTo.ID) {
+			item.ReplyTo = nil
+		}
+		if item.RepostTo != nil && !lo.Contains(readable, item.RepostTo.ID) {
+			item.RepostTo = nil
+		}
+	}
+
+	return nil
+}
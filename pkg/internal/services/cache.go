@@ -0,0 +1,172 @@
+package services
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+type CacheStats struct {
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	HitRate float64 `json:"hit_rate"`
+	Size    int     `json:"size"`
+}
+
+type cacheEntry[V any] struct {
+	value     V
+	expiredAt time.Time
+}
+
+// ttlCache is an in-memory cache, the entries expire after the duration
+// configured by the ttl setting key.
+type ttlCache[K comparable, V any] struct {
+	ttlKey     string
+	defaultTTL time.Duration
+
+	mutex   sync.RWMutex
+	entries map[K]cacheEntry[V]
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+func newTTLCache[K comparable, V any](ttlKey string, defaultTTL time.Duration) *ttlCache[K, V] {
+	return &ttlCache[K, V]{
+		ttlKey:     ttlKey,
+		defaultTTL: defaultTTL,
+		entries:    make(map[K]cacheEntry[V]),
+	}
+}
+
+func (v *ttlCache[K, V]) ttl() time.Duration {
+	if ttl := viper.GetDuration(v.ttlKey); ttl > 0 {
+		return ttl
+	}
+	return v.defaultTTL
+}
+
+// Get returns the unexpired value of the key and counts the hit or miss.
+func (v *ttlCache[K, V]) Get(key K) (V, bool) {
+	v.mutex.RLock()
+	entry, ok := v.entries[key]
+	v.mutex.RUnlock()
+
+	if ok && time.Now().Before(entry.expiredAt) {
+		v.hits.Add(1)
+		return entry.value, true
+	}
+
+	v.misses.Add(1)
+	var empty V
+	return empty, false
+}
+
+// GetStale returns the value of the key even it was expired.
+func (v *ttlCache[K, V]) GetStale(key K) (V, bool) {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+
+	entry, ok := v.entries[key]
+	return entry.value, ok
+}
+
+func (v *ttlCache[K, V]) Set(key K, value V) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	v.entries[key] = cacheEntry[V]{value: value, expiredAt: time.Now().Add(v.ttl())}
+}
+
+func (v *ttlCache[K, V]) Delete(key K) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	delete(v.entries, key)
+}
+
+func (v *ttlCache[K, V]) Clear() {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	v.entries = make(map[K]cacheEntry[V])
+}
+
+// Prune drops the entries expired for a while, the recently expired ones
+// are kept as the stale fallback.
+func (v *ttlCache[K, V]) Prune() {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	deadline := time.Now().Add(-v.ttl())
+	for key, entry := range v.entries {
+		if entry.expiredAt.Before(deadline) {
+			delete(v.entries, key)
+		}
+	}
+}
+
+func (v *ttlCache[K, V]) Stats() CacheStats {
+	v.mutex.RLock()
+	size := len(v.entries)
+	v.mutex.RUnlock()
+
+	stats := CacheStats{Hits: v.hits.Load(), Misses: v.misses.Load(), Size: size}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
+
+func GetCacheStats() map[string]CacheStats {
+	stats := GetRelationshipCacheStats()
+	for name, item := range GetRealmCacheStats() {
+		stats[name] = item
+	}
+	return stats
+}
+
+func DoCacheMaintenance() {
+	friendCache.Prune()
+	blocklistCache.Prune()
+	realmCache.Prune()
+	realmAliasCache.Prune()
+	realmMissCache.Prune()
+
+	for name, stats := range GetCacheStats() {
+		log.Debug().
+			Str("cache", name).
+			Int64("hits", stats.Hits).
+			Int64("misses", stats.Misses).
+			Float64("rate", stats.HitRate).
+			Int("size", stats.Size).
+			Msg("Cache stats.")
+	}
+}
+
+var (
+	cacheHitsDesc   = prometheus.NewDesc("interactive_cache_hits_total", "Count of the cache hits.", []string{"cache"}, nil)
+	cacheMissesDesc = prometheus.NewDesc("interactive_cache_misses_total", "Count of the cache misses.", []string{"cache"}, nil)
+	cacheSizeDesc   = prometheus.NewDesc("interactive_cache_entries", "Count of the entries in the cache.", []string{"cache"}, nil)
+)
+
+// CacheCollector exposes the cache stats to prometheus, the stats are read when scraping.
+type CacheCollector struct{}
+
+func (CacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cacheHitsDesc
+	ch <- cacheMissesDesc
+	ch <- cacheSizeDesc
+}
+
+func (CacheCollector) Collect(ch chan<- prometheus.Metric) {
+	for name, stats := range GetCacheStats() {
+		ch <- prometheus.MustNewConstMetric(cacheHitsDesc, prometheus.CounterValue, float64(stats.Hits), name)
+		ch <- prometheus.MustNewConstMetric(cacheMissesDesc, prometheus.CounterValue, float64(stats.Misses), name)
+		ch <- prometheus.MustNewConstMetric(cacheSizeDesc, prometheus.GaugeValue, float64(stats.Size), name)
+	}
+}
@@ -1,23 +1,47 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
 	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/metrics"
 	"github.com/rs/zerolog/log"
-	"time"
 )
 
 func DoAutoDatabaseCleanup() {
+	start := time.Now()
 	deadline := time.Now().Add(60 * time.Minute)
 	log.Debug().Time("deadline", deadline).Msg("Now cleaning up entire database...")
 
 	var count int64
+	var errs []error
 	for _, model := range database.AutoMaintainRange {
+		kind := reflect.TypeOf(model).Elem()
+		if _, ok := kind.FieldByName("DeletedAt"); !ok {
+			// The models without soft deletion, like the reactions, have nothing to clean up
+			continue
+		}
+
+		name := kind.Name()
 		tx := database.C.Unscoped().Delete(model, "deleted_at >= ?", deadline)
 		if tx.Error != nil {
-			log.Error().Err(tx.Error).Msg("An error occurred when running auth context cleanup...")
+			// Keep cleaning the other models, the failed ones are reported together
+			log.Error().Err(tx.Error).Str("model", name).Msg("An error occurred when running database cleanup...")
+			errs = append(errs, fmt.Errorf("%s: %v", name, tx.Error))
+			continue
 		}
 		count += tx.RowsAffected
 	}
 
-	log.Debug().Int64("affected", count).Msg("Clean up entire database accomplished.")
+	err := errors.Join(errs...)
+	metrics.ObserveCronJob("database_cleanup", start, count, err)
+
+	if err != nil {
+		log.Warn().Err(err).Int("failed", len(errs)).Int64("affected", count).Msg("Clean up entire database partially failed.")
+	} else {
+		log.Debug().Int64("affected", count).Msg("Clean up entire database accomplished.")
+	}
 }
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	"github.com/gorilla/feeds"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+const (
+	FeedFormatRSS  = "rss"
+	FeedFormatAtom = "atom"
+	FeedFormatJSON = "json"
+)
+
+var FeedContentTypes = map[string]string{
+	FeedFormatRSS:  "application/rss+xml; charset=utf-8",
+	FeedFormatAtom: "application/atom+xml; charset=utf-8",
+	FeedFormatJSON: "application/feed+json; charset=utf-8",
+}
+
+type PostFeedInfo struct {
+	Title       string
+	Description string
+	Link        string
+	Updated     time.Time
+}
+
+// FilterPostFeed keeps the public top-level posts, the replies are left out of the feeds.
+func FilterPostFeed(tx *gorm.DB) *gorm.DB {
+	tx = FilterPostPublic(tx)
+	return FilterPostReply(tx)
+}
+
+// GetPostFeedVersion returns the last modified time and the etag of the feed,
+// it is cheap enough to answer the conditional requests without rendering.
+func GetPostFeedVersion(tx *gorm.DB, link, format string) (time.Time, string, error) {
+	var version struct {
+		Count        int64
+		LastModified *time.Time
+	}
+	table := viper.GetString("database.prefix") + "posts"
+	if err := tx.Model(&models.Post{}).
+		Select(fmt.Sprintf("COUNT(*) AS count, MAX(GREATEST(%s.updated_at, %s.published_at)) AS last_modified", table, table)).
+		Scan(&version).Error; err != nil {
+		return time.Time{}, "", fmt.Errorf("unable to get feed version: %v", err)
+	}
+
+	lastModified := time.Unix(0, 0).UTC()
+	if version.LastModified != nil {
+		lastModified = version.LastModified.UTC().Truncate(time.Second)
+	}
+
+	hash := sha1.Sum([]byte(fmt.Sprintf("%s|%s|%d|%d", link, format, version.Count, lastModified.UnixNano())))
+	return lastModified, fmt.Sprintf(`W/"%s"`, hex.EncodeToString(hash[:])), nil
+}
+
+func ListPostFeedItems(tx *gorm.DB) ([]*models.Post, error) {
+	take := viper.GetInt("feeds.size")
+	if take <= 0 {
+		take = 20
+	}
+	return ListPostMinimal(tx.Preload("Author"), take, 0, "published_at DESC")
+}
+
+func GetPostLink(post models.Post) string {
+	return fmt.Sprintf(viper.GetString("feeds.post_url"), post.ID)
+}
+
+func RenderPostFeed(info PostFeedInfo, items []*models.Post, format string) (string, error) {
+	feed := &feeds.Feed{
+		Title:       info.Title,
+		Description: info.Description,
+		Link:        &feeds.Link{Href: info.Link},
+		Id:          info.Link,
+		Updated:     info.Updated,
+	}
+
+	for _, item := range items {
+		EnsurePostRendered(item)
+
+		title, _ := item.Body["title"].(string)
+		description, _ := item.Body["description"].(string)
+		if len(title) == 0 {
+			title = TruncatePostContentShort(item.PlaintextContent)
+		}
+		if len(description) == 0 {
+			description = item.PlaintextContent
+			if runes := []rune(description); len(runes) > TruncatePostContentThreshold {
+				description = string(runes[:TruncatePostContentThreshold]) + "..."
+			}
+		}
+
+		entry := &feeds.Item{
+			Id:          GetPostLink(*item),
+			Title:       title,
+			Link:        &feeds.Link{Href: GetPostLink(*item)},
+			Author:      &feeds.Author{Name: item.Author.Nick},
+			Description: description,
+			Content:     item.RenderedContent,
+			Created:     item.CreatedAt,
+			Updated:     item.UpdatedAt,
+		}
+		if item.PublishedAt != nil {
+			entry.Created = *item.PublishedAt
+		}
+		if item.EditedAt != nil {
+			entry.Updated = *item.EditedAt
+		}
+		if entry.Updated.After(feed.Updated) {
+			feed.Updated = entry.Updated
+		}
+
+		feed.Items = append(feed.Items, entry)
+	}
+
+	switch format {
+	case FeedFormatRSS:
+		return feed.ToRss()
+	case FeedFormatAtom:
+		return feed.ToAtom()
+	case FeedFormatJSON:
+		return feed.ToJSON()
+	}
+	return "", fmt.Errorf("unknown feed format %s", format)
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp/syntax"
+	"strings"
+	"time"
+
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	"github.com/samber/lo"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+func ListMuteRules(user models.Account) ([]models.MuteRule, error) {
+	var rules []models.MuteRule
+	if err := database.C.
+		Where("account_id = ?", user.ID).
+		Order("created_at DESC").
+		Find(&rules).Error; err != nil {
+		return rules, err
+	}
+
+	return rules, nil
+}
+
+func ListActiveMuteRules(user models.Account) ([]models.MuteRule, error) {
+	var rules []models.MuteRule
+	if err := database.C.
+		Where("account_id = ?", user.ID).
+		Where("expired_at IS NULL OR expired_at > ?", time.Now()).
+		Find(&rules).Error; err != nil {
+		return rules, err
+	}
+
+	return rules, nil
+}
+
+func GetMuteRuleWithUser(id uint, user models.Account) (models.MuteRule, error) {
+	var rule models.MuteRule
+	if err := database.C.
+		Where("id = ? AND account_id = ?", id, user.ID).
+		First(&rule).Error; err != nil {
+		return rule, err
+	}
+	return rule, nil
+}
+
+const (
+	maxMuteRegexLength = 256
+	maxMuteRegexNodes  = 64
+)
+
+// validateMuteRegex rejects the patterns that are too long or too expensive to run on every post.
+// Nested repetitions like (a+)+ are the ones that backtrack, so they are not allowed at all.
+func validateMuteRegex(pattern string) error {
+	if len(pattern) > maxMuteRegexLength {
+		return fmt.Errorf("regular expression cannot be longer than %d characters", maxMuteRegexLength)
+	}
+
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return fmt.Errorf("invalid regular expression: %v", err)
+	}
+
+	var nodes int
+	var walk func(re *syntax.Regexp, isRepeated bool) error
+	walk = func(re *syntax.Regexp, isRepeated bool) error {
+		if nodes++; nodes > maxMuteRegexNodes {
+			return fmt.Errorf("regular expression is too complex")
+		}
+		switch re.Op {
+		case syntax.OpStar, syntax.OpPlus, syntax.OpRepeat:
+			if isRepeated {
+				return fmt.Errorf("regular expression cannot nest repetitions")
+			}
+			isRepeated = true
+		}
+		for _, sub := range re.Sub {
+			if err := walk(sub, isRepeated); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return walk(re, false)
+}
+
+func validateMuteRule(rule *models.MuteRule) error {
+	rule.Value = strings.TrimSpace(rule.Value)
+	if len(rule.Value) == 0 {
+		return fmt.Errorf("mute rule value cannot be empty")
+	}
+	if rule.ExpiredAt != nil && rule.ExpiredAt.Before(time.Now()) {
+		return fmt.Errorf("mute rule expired time must be in the future")
+	}
+
+	switch rule.Type {
+	case models.MuteRuleWord:
+		return nil
+	case models.MuteRuleRegex:
+		if err := validateMuteRegex(rule.Value); err != nil {
+			return err
+		}
+		// Let the database compile the pattern, it is the one going to run it
+		var matched bool
+		if err := database.C.Raw("SELECT '' ~* ?", rule.Value).Scan(&matched).Error; err != nil {
+			return fmt.Errorf("invalid regular expression: %v", err)
+		}
+	case models.MuteRuleTag, models.MuteRuleCategory:
+		rule.Value = strings.ToLower(rule.Value)
+	case models.MuteRuleRealm:
+		if _, err := GetRealmWithAlias(context.Background(), rule.Value); err != nil {
+			return fmt.Errorf("realm was not found: %v", err)
+		}
+	default:
+		return fmt.Errorf("unknown mute rule type %s", rule.Type)
+	}
+
+	return nil
+}
+
+func NewMuteRule(user models.Account, rule models.MuteRule) (models.MuteRule, error) {
+	rule.AccountID = user.ID
+	if err := validateMuteRule(&rule); err != nil {
+		return rule, err
+	}
+
+	err := database.C.Save(&rule).Error
+
+	return rule, err
+}
+
+func EditMuteRule(rule models.MuteRule, kind, value string, expiredAt *time.Time) (models.MuteRule, error) {
+	rule.Type = kind
+	rule.Value = value
+	rule.ExpiredAt = expiredAt
+	if err := validateMuteRule(&rule); err != nil {
+		return rule, err
+	}
+
+	err := database.C.Save(&rule).Error
+
+	return rule, err
+}
+
+func DeleteMuteRule(rule models.MuteRule) error {
+	return database.C.Delete(&rule).Error
+}
+
+// FilterPostWithMuteRules hides the posts matching any of the rules.
+func FilterPostWithMuteRules(tx *gorm.DB, rules []models.MuteRule) *gorm.DB {
+	values := func(kind string) []string {
+		return lo.FilterMap(rules, func(item models.MuteRule, index int) (string, bool) {
+			return item.Value, item.Type == kind
+		})
+	}
+
+	prefix := viper.GetString("database.prefix")
+	content := "(COALESCE(body->>'title', '') || ' ' || COALESCE(plaintext_content, body->>'content', ''))"
+
+	for _, word := range values(models.MuteRuleWord) {
+		escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(word)
+		tx = tx.Where(fmt.Sprintf("%s NOT ILIKE ?", content), "%"+escaped+"%")
+	}
+	for _, pattern := range values(models.MuteRuleRegex) {
+		tx = tx.Where(fmt.Sprintf("%s !~* ?", content), pattern)
+	}
+	if tags := values(models.MuteRuleTag); len(tags) > 0 {
+		tx = tx.Where(fmt.Sprintf(
+			"NOT EXISTS (SELECT 1 FROM %spost_tags JOIN %stags ON %stags.id = %spost_tags.tag_id WHERE %spost_tags.post_id = %sposts.id AND %stags.alias IN ?)",
+			prefix, prefix, prefix, prefix, prefix, prefix, prefix,
+		), tags)
+	}
+	if categories := values(models.MuteRuleCategory); len(categories) > 0 {
+		// Rules created before the values were lowercased still have their original case
+		categories = lo.Map(categories, func(item string, index int) string {
+			return strings.ToLower(item)
+		})
+		tx = tx.Where(fmt.Sprintf(
+			"NOT EXISTS (SELECT 1 FROM %spost_categories JOIN %scategories ON %scategories.id = %spost_categories.category_id WHERE %spost_categories.post_id = %sposts.id AND LOWER(%scategories.alias) IN ?)",
+			prefix, prefix, prefix, prefix, prefix, prefix, prefix,
+		), categories)
+	}
+	if realms := values(models.MuteRuleRealm); len(realms) > 0 {
+		tx = tx.Where(fmt.Sprintf(
+			"realm_id IS NULL OR realm_id NOT IN (SELECT id FROM %srealms WHERE alias IN ?)",
+			prefix,
+		), realms)
+	}
+
+	return tx
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateMuteRegex(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		valid   bool
+	}{
+		{"word", "spoiler", true},
+		{"alternation", "(cat|dog)s?", true},
+		{"single repetition", "buy.*now", true},
+		{"bounded repetition", "a{2,5}b", true},
+		{"nested star", "(a*)*", false},
+		{"nested plus", "(a+)+b", false},
+		{"nested bounded", "(ab{2,3})+", false},
+		{"too long", strings.Repeat("a", maxMuteRegexLength+1), false},
+		{"too complex", strings.Repeat("(a|b)", maxMuteRegexNodes), false},
+		{"invalid", "(unclosed", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateMuteRegex(tc.pattern)
+			if tc.valid && err != nil {
+				t.Errorf("expected %q to be valid, got %v", tc.pattern, err)
+			} else if !tc.valid && err == nil {
+				t.Errorf("expected %q to be rejected", tc.pattern)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/metrics"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/tracing"
+)
+
+// observeGrpc traces and measures an outgoing grpc call, pass the returned context to the call
+// and call the returned function with the result of it.
+func observeGrpc(ctx context.Context, service, method string) (context.Context, func(error)) {
+	ctx, end := tracing.StartGrpcSpan(ctx, service, method)
+	done := metrics.ObserveGrpcCall(strings.ToLower(service), method)
+	return ctx, func(err error) {
+		done(err)
+		end(err)
+	}
+}
@@ -1,27 +1,144 @@
 package services
 
 import (
+	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/pemistahl/lingua-go"
+	"github.com/rs/zerolog/log"
+	"github.com/samber/lo"
+	"github.com/spf13/viper"
 )
 
-var detector lingua.LanguageDetector
+var (
+	detector     lingua.LanguageDetector
+	detectorLock sync.RWMutex
+)
+
+// CreateLanguageDetector builds a detector with the languages and the accuracy mode in the settings,
+// all the languages are detected when the list is empty.
+func CreateLanguageDetector() (lingua.LanguageDetector, error) {
+	var builder lingua.LanguageDetectorBuilder
+	if names := viper.GetStringSlice("language.languages"); len(names) > 0 {
+		var languages []lingua.Language
+		for _, name := range names {
+			lang := NormalizeLanguage(name)
+			if len(lang) == 0 {
+				return nil, fmt.Errorf("unknown language %s", name)
+			}
+			languages = append(languages, lookupLanguage(lang))
+		}
+		languages = lo.Uniq(languages)
+		if len(languages) < 2 {
+			return nil, fmt.Errorf("language detector needs at least two languages")
+		}
+		builder = lingua.NewLanguageDetectorBuilder().FromLanguages(languages...)
+	} else {
+		builder = lingua.NewLanguageDetectorBuilder().FromAllLanguages()
+	}
+
+	switch viper.GetString("language.accuracy") {
+	case "low":
+		builder = builder.WithLowAccuracyMode()
+	case "high", "":
+	default:
+		return nil, fmt.Errorf("unknown language detection accuracy %s", viper.GetString("language.accuracy"))
+	}
 
-func CreateLanguageDetector() lingua.LanguageDetector {
-	return lingua.NewLanguageDetectorBuilder().
-		FromAllLanguages().
-		WithLowAccuracyMode().
-		Build()
+	if viper.GetBool("language.preload") {
+		builder = builder.WithPreloadedLanguageModels()
+	}
+
+	return builder.Build(), nil
 }
 
-func DetectLanguage(content string) string {
+// InitLanguageDetector replaces the detector in use, call it once at startup
+// to keep the first posts from waiting for the language models to load.
+func InitLanguageDetector() error {
+	instance, err := CreateLanguageDetector()
+	if err != nil {
+		return err
+	}
+
+	detectorLock.Lock()
+	detector = instance
+	detectorLock.Unlock()
+
+	return nil
+}
+
+func getLanguageDetector() lingua.LanguageDetector {
+	detectorLock.RLock()
+	instance := detector
+	detectorLock.RUnlock()
+	if instance != nil {
+		return instance
+	}
+
+	detectorLock.Lock()
+	defer detectorLock.Unlock()
 	if detector == nil {
-		detector = CreateLanguageDetector()
+		var err error
+		if detector, err = CreateLanguageDetector(); err != nil {
+			log.Warn().Err(err).Msg("Unable to create language detector from settings, fallback to all languages...")
+			detector = lingua.NewLanguageDetectorBuilder().FromAllLanguages().Build()
+		}
 	}
+	return detector
+}
+
+const UnknownLanguage = "unknown"
+
+func DetectLanguage(content string) string {
+	lang, _ := DetectLanguageWithConfidence(content)
+	return lang
+}
+
+// DetectLanguageWithConfidence returns the most likely language and its
+// confidence, the language is unknown when the confidence is too low.
+func DetectLanguageWithConfidence(content string) (string, float64) {
+	if len(strings.TrimSpace(content)) == 0 {
+		return UnknownLanguage, 0
+	}
+
+	values := getLanguageDetector().ComputeLanguageConfidenceValues(content)
+	if len(values) == 0 || values[0].Value() == 0 {
+		return UnknownLanguage, 0
+	} else if len(values) > 1 && values[0].Value() == values[1].Value() {
+		return UnknownLanguage, 0
+	}
+
+	confidence := values[0].Value()
+	if confidence < viper.GetFloat64("language.min_confidence") {
+		return UnknownLanguage, confidence
+	}
+	return strings.ToLower(values[0].Language().String()), confidence
+}
+
+// NormalizeLanguage converts an ISO 639-1 code (with optional region) or a
+// language name into the form stored in Post.Language.
+func NormalizeLanguage(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	lang, _, _ = strings.Cut(strings.ReplaceAll(lang, "_", "-"), "-")
+
+	if code := lingua.GetIsoCode639_1FromValue(lang); code != lingua.UnknownIsoCode639_1 {
+		return strings.ToLower(lingua.GetLanguageFromIsoCode639_1(code).String())
+	}
+	for _, item := range lingua.AllLanguages() {
+		if strings.ToLower(item.String()) == lang {
+			return lang
+		}
+	}
+
+	return ""
+}
 
-	if lang, ok := detector.DetectLanguageOf(content); ok {
-		return strings.ToLower(lang.String())
+func lookupLanguage(lang string) lingua.Language {
+	for _, item := range lingua.AllLanguages() {
+		if strings.ToLower(item.String()) == lang {
+			return item
+		}
 	}
-	return "unknown"
+	return lingua.Unknown
 }
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"git.solsynth.dev/hydrogen/dealer/pkg/hyper"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	"github.com/rs/zerolog/log"
+	"github.com/samber/lo"
+	"github.com/spf13/viper"
+	"golang.org/x/net/html"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LinkPreviewFetcher interface {
+	FetchLinkPreview(ctx context.Context, url string) (models.LinkPreview, error)
+}
+
+var linkFetcher LinkPreviewFetcher = NewOpenGraphFetcher()
+
+func SetLinkPreviewFetcher(fetcher LinkPreviewFetcher) {
+	linkFetcher = fetcher
+}
+
+// StubLinkPreviewFetcher serves link previews from memory, it is used to run
+// the server locally or in tests without reaching the internet.
+type StubLinkPreviewFetcher map[string]models.LinkPreview
+
+func (v StubLinkPreviewFetcher) FetchLinkPreview(ctx context.Context, url string) (models.LinkPreview, error) {
+	if preview, ok := v[url]; ok {
+		preview.URL = url
+		return preview, nil
+	}
+	return models.LinkPreview{URL: url}, nil
+}
+
+type OpenGraphFetcher struct {
+	client *http.Client
+}
+
+// newPublicNetworkClient creates a http client refusing to connect the internal addresses,
+// use it to reach the urls coming from the users or other servers.
+func newPublicNetworkClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() {
+				return fmt.Errorf("unable to connect internal address %s", host)
+			}
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+	}
+}
+
+func NewOpenGraphFetcher() *OpenGraphFetcher {
+	return &OpenGraphFetcher{
+		client: newPublicNetworkClient(10 * time.Second),
+	}
+}
+
+const LinkPreviewBodyLimit = 1024 * 1024
+
+func (v *OpenGraphFetcher) FetchLinkPreview(ctx context.Context, url string) (models.LinkPreview, error) {
+	preview := models.LinkPreview{URL: url}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return preview, err
+	}
+	req.Header.Set("User-Agent", "Hydrogen.Interactive LinkPreview")
+	req.Header.Set("Accept", "text/html")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return preview, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return preview, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	if mimetype, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mimetype != "text/html" {
+		return preview, fmt.Errorf("unsupported content type %s", mimetype)
+	}
+
+	tokenizer := html.NewTokenizer(io.LimitReader(resp.Body, LinkPreviewBodyLimit))
+	var inTitle bool
+	var title string
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			if preview.Title == nil && len(title) > 0 {
+				preview.Title = &title
+			}
+			if errors.Is(tokenizer.Err(), io.EOF) {
+				return preview, nil
+			}
+			return preview, tokenizer.Err()
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "title":
+				inTitle = true
+			case "meta":
+				applyOpenGraphMeta(&preview, token)
+			case "body":
+				if preview.Title == nil && len(title) > 0 {
+					preview.Title = &title
+				}
+				return preview, nil
+			}
+		case html.TextToken:
+			if inTitle {
+				title = strings.TrimSpace(string(tokenizer.Text()))
+				inTitle = false
+			}
+		case html.EndTagToken:
+			inTitle = false
+		}
+	}
+}
+
+func applyOpenGraphMeta(preview *models.LinkPreview, token html.Token) {
+	var key, content string
+	for _, attr := range token.Attr {
+		switch attr.Key {
+		case "property", "name":
+			key = strings.ToLower(attr.Val)
+		case "content":
+			content = strings.TrimSpace(attr.Val)
+		}
+	}
+	if len(content) == 0 {
+		return
+	}
+
+	switch key {
+	case "og:title":
+		preview.Title = &content
+	case "og:description":
+		preview.Description = &content
+	case "description":
+		if preview.Description == nil {
+			preview.Description = &content
+		}
+	case "og:image":
+		preview.Image = &content
+	case "og:site_name":
+		preview.SiteName = &content
+	case "og:type":
+		preview.Type = &content
+	}
+}
+
+var linkPattern = regexp.MustCompile(`https?://[^\s<>"'()\[\]]+`)
+
+func ExtractLinks(content string) []string {
+	links := linkPattern.FindAllString(content, -1)
+	links = lo.Map(links, func(item string, index int) string {
+		return strings.TrimRight(item, ".,;:!?")
+	})
+	return lo.Uniq(links)
+}
+
+func GetLinkPreview(ctx context.Context, url string) (models.LinkPreview, error) {
+	var preview models.LinkPreview
+	if err := database.C.Where("url = ?", url).First(&preview).Error; err == nil {
+		ttl := viper.GetDuration("link_preview.ttl")
+		if time.Since(preview.FetchedAt) < ttl {
+			return preview, nil
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return preview, err
+	}
+
+	fetched, err := linkFetcher.FetchLinkPreview(ctx, url)
+	if err != nil {
+		return preview, fmt.Errorf("unable to fetch link preview: %v", err)
+	}
+	fetched.BaseModel = preview.BaseModel
+	fetched.URL = url
+	fetched.FetchedAt = time.Now()
+
+	if fetched.ID > 0 {
+		err = database.C.Save(&fetched).Error
+		return fetched, err
+	}
+
+	// Another post may link the same url at the same time, keep whichever saved first
+	if err := database.C.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "url"}},
+		DoNothing: true,
+	}).Create(&fetched).Error; err != nil {
+		return fetched, err
+	}
+	if err := database.C.Where("url = ?", url).First(&preview).Error; err != nil {
+		return fetched, err
+	}
+	return preview, nil
+}
+
+func LinkPostPreviews(item models.Post) {
+	content, ok := item.Body["content"].(string)
+	if !ok {
+		return
+	}
+
+	links := ExtractLinks(content)
+	if limit := viper.GetInt("link_preview.max_per_post"); len(links) > limit {
+		links = links[:limit]
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	var previews []models.LinkPreview
+	for _, link := range links {
+		preview, err := GetLinkPreview(ctx, link)
+		if err != nil {
+			log.Debug().Err(err).Str("url", link).Msg("Unable to get link preview, skipped...")
+			continue
+		}
+		previews = append(previews, preview)
+	}
+
+	post := models.Post{BaseModel: hyper.BaseModel{ID: item.ID}}
+	if err := database.C.Model(&post).Association("Links").Replace(previews); err != nil {
+		log.Error().Err(err).Uint("post", item.ID).Msg("An error occurred when linking post previews...")
+	}
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	"github.com/samber/lo"
+	"github.com/spf13/viper"
+)
+
+func TestExtractLinks(t *testing.T) {
+	tests := []struct {
+		content string
+		want    []string
+	}{
+		{"no links here", nil},
+		{"see https://example.com.", []string{"https://example.com"}},
+		{"(http://a.test/x) and http://a.test/x again", []string{"http://a.test/x"}},
+		{`<a href="https://b.test/?q=1">`, []string{"https://b.test/?q=1"}},
+	}
+
+	for _, tt := range tests {
+		got := ExtractLinks(tt.content)
+		if len(got) != len(tt.want) {
+			t.Errorf("ExtractLinks(%q) = %v, want %v", tt.content, got, tt.want)
+			continue
+		}
+		for idx := range got {
+			if got[idx] != tt.want[idx] {
+				t.Errorf("ExtractLinks(%q) = %v, want %v", tt.content, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestOpenGraphFetcher(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(`<html><head>
+<title>Fallback</title>
+<meta property="og:title" content="Open Graph">
+<meta name="description" content="Plain description">
+<meta property="og:image" content="https://example.com/a.png">
+</head><body>ignored</body></html>`))
+	}))
+	defer server.Close()
+
+	// The default client refuses the loopback address of the test server
+	fetcher := &OpenGraphFetcher{client: server.Client()}
+	preview, err := fetcher.FetchLinkPreview(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lo.FromPtr(preview.Title) != "Open Graph" {
+		t.Errorf("title = %q, want %q", lo.FromPtr(preview.Title), "Open Graph")
+	}
+	if lo.FromPtr(preview.Description) != "Plain description" {
+		t.Errorf("description = %q, want %q", lo.FromPtr(preview.Description), "Plain description")
+	}
+	if lo.FromPtr(preview.Image) != "https://example.com/a.png" {
+		t.Errorf("image = %q, want %q", lo.FromPtr(preview.Image), "https://example.com/a.png")
+	}
+}
+
+func TestGetLinkPreviewConcurrently(t *testing.T) {
+	requireDatabase(t)
+
+	const url = "https://example.com/shared"
+	SetLinkPreviewFetcher(StubLinkPreviewFetcher{
+		url: {Title: lo.ToPtr("Shared")},
+	})
+	defer SetLinkPreviewFetcher(NewOpenGraphFetcher())
+	viper.Set("link_preview.ttl", "1h")
+
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	previews := make([]models.LinkPreview, 8)
+	for idx := range errs {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			previews[idx], errs[idx] = GetLinkPreview(context.Background(), url)
+		}(idx)
+	}
+	wg.Wait()
+
+	for idx, err := range errs {
+		if err != nil {
+			t.Fatalf("GetLinkPreview #%d: %v", idx, err)
+		}
+		if previews[idx].ID == 0 || previews[idx].ID != previews[0].ID {
+			t.Errorf("GetLinkPreview #%d returned preview %d, want %d", idx, previews[idx].ID, previews[0].ID)
+		}
+	}
+
+	var count int64
+	database.C.Model(&models.LinkPreview{}).Where("url = ?", url).Count(&count)
+	if count != 1 {
+		t.Errorf("saved %d previews, want 1", count)
+	}
+}
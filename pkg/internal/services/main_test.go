@@ -0,0 +1,88 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+	"github.com/spf13/viper"
+)
+
+// testDatabaseErr is set when neither the database in INTERACTIVE_TEST_DSN
+// nor the embedded one is available, the tests need it are skipped then.
+var testDatabaseErr error
+
+func TestMain(m *testing.M) {
+	stop, err := setupTestDatabase()
+	if err != nil {
+		testDatabaseErr = err
+	}
+
+	code := m.Run()
+	if stop != nil {
+		stop()
+	}
+	os.Exit(code)
+}
+
+func setupTestDatabase() (func(), error) {
+	var stop func()
+
+	dsn := os.Getenv("INTERACTIVE_TEST_DSN")
+	if len(dsn) == 0 {
+		runtime, err := os.MkdirTemp("", "interactive-postgres-")
+		if err != nil {
+			return nil, err
+		}
+		pg := embeddedpostgres.NewDatabase(embeddedpostgres.DefaultConfig().
+			Port(15432).
+			RuntimePath(runtime).
+			Logger(nil))
+		if err := pg.Start(); err != nil {
+			_ = os.RemoveAll(runtime)
+			return nil, fmt.Errorf("unable to start embedded postgres: %v", err)
+		}
+		stop = func() {
+			_ = pg.Stop()
+			_ = os.RemoveAll(runtime)
+		}
+		dsn = "host=localhost port=15432 user=postgres password=postgres dbname=postgres sslmode=disable"
+	}
+
+	viper.Set("database.dsn", dsn)
+	viper.Set("database.prefix", "interactive_test_")
+	if err := database.NewSource(); err != nil {
+		return stop, err
+	} else if err := database.RunMigration(database.C); err != nil {
+		return stop, err
+	}
+
+	return stop, nil
+}
+
+// requireDatabase skips the test without a database and empties the tables for it.
+func requireDatabase(t *testing.T) {
+	t.Helper()
+	if testDatabaseErr != nil {
+		t.Skipf("database is not available: %v", testDatabaseErr)
+	}
+
+	tables, err := database.C.Migrator().GetTables()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var prefixed []string
+	for _, table := range tables {
+		if strings.HasPrefix(table, viper.GetString("database.prefix")) {
+			prefixed = append(prefixed, `"`+table+`"`)
+		}
+	}
+	if len(prefixed) > 0 {
+		if err := database.C.Exec("TRUNCATE " + strings.Join(prefixed, ", ") + " RESTART IDENTITY CASCADE").Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	"github.com/samber/lo"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func GetRealmReadMarkerFeed(realm models.Realm) string {
+	return fmt.Sprintf("%s:%d", models.ReadMarkerFeedRealm, realm.ID)
+}
+
+// FilterPostWithSubscriptions keeps the posts from the accounts, tags, categories and realms the user subscribed.
+func FilterPostWithSubscriptions(tx *gorm.DB, user models.Account) *gorm.DB {
+	prefix := viper.GetString("database.prefix")
+	subscriptions := func(column string) *gorm.DB {
+		return database.C.Model(&models.Subscription{}).
+			Select(column).
+			Where("follower_id = ? AND "+column+" IS NOT NULL", user.ID)
+	}
+
+	return tx.Where(
+		database.C.Where("author_id IN (?)", subscriptions("account_id")).
+			Or("realm_id IN (?)", subscriptions("realm_id")).
+			Or(fmt.Sprintf("EXISTS (SELECT 1 FROM %spost_tags WHERE %spost_tags.post_id = %sposts.id AND %spost_tags.tag_id IN (?))", prefix, prefix, prefix, prefix), subscriptions("tag_id")).
+			Or(fmt.Sprintf("EXISTS (SELECT 1 FROM %spost_categories WHERE %spost_categories.post_id = %sposts.id AND %spost_categories.category_id IN (?))", prefix, prefix, prefix, prefix), subscriptions("category_id")),
+	)
+}
+
+// GetReadMarker gets the read marker of the feed, an empty marker is returned when the user never read it.
+func GetReadMarker(user models.Account, feed string) (models.ReadMarker, error) {
+	var marker models.ReadMarker
+	if err := database.C.
+		Where("account_id = ? AND feed = ?", user.ID, feed).
+		First(&marker).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.ReadMarker{Feed: feed, AccountID: user.ID}, nil
+		}
+		return marker, fmt.Errorf("unable to get read marker: %v", err)
+	}
+	return marker, nil
+}
+
+func ListReadMarkers(user models.Account) ([]models.ReadMarker, error) {
+	var markers []models.ReadMarker
+	if err := database.C.
+		Where("account_id = ?", user.ID).
+		Find(&markers).Error; err != nil {
+		return markers, err
+	}
+	return markers, nil
+}
+
+// postReadOrder is the order of the posts in the feeds, the read markers follow it
+const postReadOrder = "COALESCE(published_at, created_at)"
+
+// FilterPostUnread keeps the posts after the marker in the order of the feeds,
+// the posts published at the same time are told apart by the id.
+func FilterPostUnread(tx *gorm.DB, marker models.ReadMarker) *gorm.DB {
+	if marker.LastReadAt == nil {
+		// The markers without the published time can only compare the id
+		return tx.Where("id > ?", marker.LastReadID)
+	}
+	return tx.Where(
+		fmt.Sprintf("%s > ? OR (%s = ? AND id > ?)", postReadOrder, postReadOrder),
+		*marker.LastReadAt, *marker.LastReadAt, marker.LastReadID,
+	)
+}
+
+type postReadPivot struct {
+	ID          uint
+	PublishedAt *time.Time
+}
+
+// GetPostReadMarker returns the marker pointing to the post, the deleted posts can still be the pivot.
+func GetPostReadMarker(id uint) (models.ReadMarker, error) {
+	var pivot postReadPivot
+	if err := database.C.Unscoped().Model(&models.Post{}).
+		Select("id, "+postReadOrder+" AS published_at").
+		Where("id = ?", id).
+		Take(&pivot).Error; err != nil {
+		return models.ReadMarker{}, fmt.Errorf("pivot post was not found: %v", err)
+	}
+	return models.ReadMarker{LastReadID: pivot.ID, LastReadAt: pivot.PublishedAt}, nil
+}
+
+// GetLatestPostReadMarker returns the marker pointing to the newest post in the query,
+// an empty marker is returned when the query is empty.
+func GetLatestPostReadMarker(tx *gorm.DB) (models.ReadMarker, error) {
+	var pivots []postReadPivot
+	if err := tx.Model(&models.Post{}).
+		Select("id, " + postReadOrder + " AS published_at").
+		Order(postReadOrder + " DESC, id DESC").
+		Limit(1).
+		Scan(&pivots).Error; err != nil || len(pivots) == 0 {
+		return models.ReadMarker{}, err
+	}
+	return models.ReadMarker{LastReadID: pivots[0].ID, LastReadAt: pivots[0].PublishedAt}, nil
+}
+
+// MarkFeedAsRead moves the read marker of the feed to the pivot, the marker never moves backwards.
+func MarkFeedAsRead(user models.Account, feed string, pivot models.ReadMarker) (models.ReadMarker, error) {
+	marker := models.ReadMarker{
+		Feed:       feed,
+		LastReadID: pivot.LastReadID,
+		LastReadAt: pivot.LastReadAt,
+		ReadAt:     lo.ToPtr(time.Now()),
+		AccountID:  user.ID,
+	}
+
+	table := viper.GetString("database.prefix") + "read_markers"
+	if err := database.C.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "feed"}, {Name: "account_id"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"last_read_id": gorm.Expr("EXCLUDED.last_read_id"),
+			"last_read_at": gorm.Expr("EXCLUDED.last_read_at"),
+			"read_at":      marker.ReadAt,
+			"updated_at":   time.Now(),
+		}),
+		Where: clause.Where{Exprs: []clause.Expression{gorm.Expr(fmt.Sprintf(
+			"(COALESCE(%s.last_read_at, '-infinity'), %s.last_read_id) < (COALESCE(EXCLUDED.last_read_at, '-infinity'), EXCLUDED.last_read_id)",
+			table, table,
+		))}},
+	}).Create(&marker).Error; err != nil {
+		return marker, fmt.Errorf("unable to mark feed as read: %v", err)
+	}
+
+	return GetReadMarker(user, feed)
+}
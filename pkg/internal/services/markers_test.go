@@ -0,0 +1,79 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	"github.com/samber/lo"
+	"gorm.io/datatypes"
+)
+
+func TestReadMarkerFollowsPublishedAt(t *testing.T) {
+	requireDatabase(t)
+
+	user := createTestAccount(t, "reader")
+	now := time.Now().Truncate(time.Second)
+
+	// The ids are in the order of creating, the published time is in another order
+	newPost := func(publishedAt time.Time) models.Post {
+		post := models.Post{
+			Type:        models.PostTypeStory,
+			Body:        datatypes.JSONMap{"content": "Hello"},
+			Visibility:  models.PostVisibilityAll,
+			PublishedAt: lo.ToPtr(publishedAt),
+			AuthorID:    user.ID,
+		}
+		if err := database.C.Create(&post).Error; err != nil {
+			t.Fatal(err)
+		}
+		return post
+	}
+	late := newPost(now.Add(-time.Minute))
+	early := newPost(now.Add(-time.Hour))
+	tied := newPost(now.Add(-time.Minute))
+
+	unread := func(marker models.ReadMarker) []uint {
+		t.Helper()
+		var id []uint
+		if err := FilterPostUnread(database.C.Model(&models.Post{}), marker).
+			Order("id ASC").
+			Pluck("id", &id).Error; err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+
+	pivot, err := GetPostReadMarker(early.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := unread(pivot); len(got) != 2 || got[0] != late.ID || got[1] != tied.ID {
+		t.Errorf("unread after the early post = %v, want %v", got, []uint{late.ID, tied.ID})
+	}
+
+	marker, err := MarkFeedAsRead(user, models.ReadMarkerFeedGlobal, pivot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest, err := GetLatestPostReadMarker(database.C)
+	if err != nil {
+		t.Fatal(err)
+	} else if latest.LastReadID != tied.ID {
+		t.Errorf("latest post = %d, want %d", latest.LastReadID, tied.ID)
+	}
+	if marker, err = MarkFeedAsRead(user, models.ReadMarkerFeedGlobal, latest); err != nil {
+		t.Fatal(err)
+	}
+	if got := unread(marker); len(got) != 0 {
+		t.Errorf("unread after the latest post = %v, want none", got)
+	}
+
+	// The marker never moves backwards to an earlier published post
+	if marker, err = MarkFeedAsRead(user, models.ReadMarkerFeedGlobal, pivot); err != nil {
+		t.Fatal(err)
+	} else if marker.LastReadID != tied.ID {
+		t.Errorf("marker moved backwards to %d", marker.LastReadID)
+	}
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	"gorm.io/gorm"
+)
+
+func activeAccountMutes() *gorm.DB {
+	return database.C.Model(&models.AccountMute{}).
+		Where("expired_at IS NULL OR expired_at > ?", time.Now())
+}
+
+// mutedAccountsOf is a subquery of the accounts muted by the user
+func mutedAccountsOf(user uint) *gorm.DB {
+	return activeAccountMutes().Select("muted_id").Where("account_id = ?", user)
+}
+
+// mutingAccountsOf is a subquery of the accounts muting the user
+func mutingAccountsOf(user uint) *gorm.DB {
+	return activeAccountMutes().Select("account_id").Where("muted_id = ?", user)
+}
+
+func ListAccountMutes(user models.Account) ([]models.AccountMute, error) {
+	var mutes []models.AccountMute
+	if err := database.C.
+		Where("account_id = ?", user.ID).
+		Where("expired_at IS NULL OR expired_at > ?", time.Now()).
+		Preload("Muted").
+		Find(&mutes).Error; err != nil {
+		return mutes, err
+	}
+
+	return mutes, nil
+}
+
+func GetAccountMute(user models.Account, target models.Account) (*models.AccountMute, error) {
+	var mute models.AccountMute
+	if err := database.C.
+		Where("account_id = ? AND muted_id = ?", user.ID, target.ID).
+		Where("expired_at IS NULL OR expired_at > ?", time.Now()).
+		First(&mute).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &mute, nil
+}
+
+func IsAccountMuted(user models.Account, target models.Account) bool {
+	mute, err := GetAccountMute(user, target)
+	return err == nil && mute != nil
+}
+
+func MuteAccount(user models.Account, target models.Account, expiredAt *time.Time) (models.AccountMute, error) {
+	mute := models.AccountMute{
+		AccountID: user.ID,
+		MutedID:   target.ID,
+	}
+	if user.ID == target.ID {
+		return mute, fmt.Errorf("you cannot mute yourself")
+	}
+	if expiredAt != nil && expiredAt.Before(time.Now()) {
+		return mute, fmt.Errorf("mute expired time must be in the future")
+	}
+
+	// Replace the previous mute, include the expired one holding the unique index
+	if err := database.C.Unscoped().
+		Where("account_id = ? AND muted_id = ?", user.ID, target.ID).
+		Delete(&models.AccountMute{}).Error; err != nil {
+		return mute, err
+	}
+
+	mute.ExpiredAt = expiredAt
+	err := database.C.Save(&mute).Error
+
+	return mute, err
+}
+
+func UnmuteAccount(user models.Account, target models.Account) error {
+	tx := database.C.Unscoped().
+		Where("account_id = ? AND muted_id = ?", user.ID, target.ID).
+		Delete(&models.AccountMute{})
+	if tx.Error != nil {
+		return tx.Error
+	} else if tx.RowsAffected == 0 {
+		return fmt.Errorf("mute does not exist")
+	}
+
+	return nil
+}
@@ -0,0 +1,321 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/metrics"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/rs/zerolog/log"
+	"github.com/samber/lo"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+var notificationRetry = retryPolicy{
+	Prefix:   "notification",
+	Base:     15 * time.Second,
+	Limit:    time.Hour,
+	Lease:    2 * time.Minute,
+	Attempts: 8,
+}
+
+// NotificationPayload keeps what the notification needs, the post content is copied
+// so the notification doesn't change when the post is edited before it is sent.
+type NotificationPayload struct {
+	RecipientID  uint    `json:"recipient_id,omitempty"`
+	TargetID     uint    `json:"target_id,omitempty"`
+	ActorID      uint    `json:"actor_id,omitempty"`
+	PostID       uint    `json:"post_id,omitempty"`
+	Title        string  `json:"title,omitempty"`
+	Subtitle     *string `json:"subtitle,omitempty"`
+	Body         string  `json:"body,omitempty"`
+	Content      string  `json:"content,omitempty"`
+	ContentTitle *string `json:"content_title,omitempty"`
+}
+
+func EnqueueNotification(tx *gorm.DB, kind string, payload NotificationPayload) error {
+	raw, err := jsoniter.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("unable to encode notification payload: %v", err)
+	}
+
+	item := models.NotificationOutbox{
+		Kind:          kind,
+		Payload:       raw,
+		Status:        models.NotificationOutboxPending,
+		NextAttemptAt: lo.ToPtr(time.Now()),
+	}
+	if err := tx.Create(&item).Error; err != nil {
+		return fmt.Errorf("unable to queue notification: %v", err)
+	}
+	return nil
+}
+
+func EnqueuePosterNotification(tx *gorm.DB, user models.Account, post models.Post, title, body string, subtitle *string) error {
+	return EnqueueNotification(tx, models.NotificationKindPoster, NotificationPayload{
+		RecipientID: user.ID,
+		PostID:      post.ID,
+		Title:       title,
+		Subtitle:    subtitle,
+		Body:        body,
+	})
+}
+
+// EnqueueSubscriptionNotifications queues the notifications to the subscribers of the author,
+// the tags and the categories of the post.
+func EnqueueSubscriptionNotifications(tx *gorm.DB, user models.Account, item models.Post) error {
+	content := item.PlaintextContent
+	title, _ := item.Body["title"].(*string)
+
+	if err := EnqueueNotification(tx, models.NotificationKindAccountSubscription, NotificationPayload{
+		TargetID:     user.ID,
+		ActorID:      user.ID,
+		PostID:       item.ID,
+		Content:      content,
+		ContentTitle: title,
+	}); err != nil {
+		return err
+	}
+	for _, tag := range item.Tags {
+		if err := EnqueueNotification(tx, models.NotificationKindTagSubscription, NotificationPayload{
+			TargetID:     tag.ID,
+			ActorID:      user.ID,
+			PostID:       item.ID,
+			Content:      content,
+			ContentTitle: title,
+		}); err != nil {
+			return err
+		}
+	}
+	for _, category := range item.Categories {
+		if err := EnqueueNotification(tx, models.NotificationKindCategorySubscription, NotificationPayload{
+			TargetID:     category.ID,
+			ActorID:      user.ID,
+			PostID:       item.ID,
+			Content:      content,
+			ContentTitle: title,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func EnqueuePollNotification(tx *gorm.DB, post models.Post) error {
+	return EnqueueNotification(tx, models.NotificationKindPollClosed, NotificationPayload{
+		PostID: post.ID,
+	})
+}
+
+func CountNotificationOutbox(status string) (int64, error) {
+	tx := database.C.Model(&models.NotificationOutbox{})
+	if len(status) > 0 {
+		tx = tx.Where("status = ?", status)
+	}
+
+	var count int64
+	err := tx.Count(&count).Error
+	return count, err
+}
+
+func ListNotificationOutbox(status string, take, offset int) ([]models.NotificationOutbox, error) {
+	if take > 100 {
+		take = 100
+	}
+
+	tx := database.C
+	if len(status) > 0 {
+		tx = tx.Where("status = ?", status)
+	}
+
+	var items []models.NotificationOutbox
+	if err := tx.
+		Limit(take).Offset(offset).
+		Order("created_at DESC").
+		Find(&items).Error; err != nil {
+		return items, err
+	}
+
+	return items, nil
+}
+
+func GetNotificationOutbox(id uint) (models.NotificationOutbox, error) {
+	var item models.NotificationOutbox
+	if err := database.C.Where("id = ?", id).First(&item).Error; err != nil {
+		return item, err
+	}
+	return item, nil
+}
+
+// RetryNotificationOutbox puts a dead notification back into the queue with the attempts reset.
+func RetryNotificationOutbox(item models.NotificationOutbox) (models.NotificationOutbox, error) {
+	if item.Status != models.NotificationOutboxDead {
+		return item, fmt.Errorf("only dead notifications can be retried")
+	}
+
+	item.Status = models.NotificationOutboxPending
+	item.Attempts = 0
+	item.NextAttemptAt = lo.ToPtr(time.Now())
+	if err := database.C.Save(&item).Error; err != nil {
+		return item, err
+	}
+
+	go DoNotificationDispatch()
+
+	return item, nil
+}
+
+func DeleteNotificationOutbox(item models.NotificationOutbox) error {
+	return database.C.Delete(&item).Error
+}
+
+func dispatchNotification(item models.NotificationOutbox) error {
+	var payload NotificationPayload
+	if err := jsoniter.Unmarshal(item.Payload, &payload); err != nil {
+		return fmt.Errorf("unable to decode notification payload: %v", err)
+	}
+
+	var actor models.Account
+	if payload.ActorID > 0 {
+		if err := database.C.Where("id = ?", payload.ActorID).First(&actor).Error; err != nil {
+			return err
+		}
+	}
+
+	switch item.Kind {
+	case models.NotificationKindPoster:
+		var user models.Account
+		if err := database.C.Where("id = ?", payload.RecipientID).First(&user).Error; err != nil {
+			return err
+		}
+		var post models.Post
+		if err := database.C.Unscoped().Where("id = ?", payload.PostID).First(&post).Error; err != nil {
+			return err
+		}
+		return NotifyPosterAccount(user, post, payload.Title, payload.Body, payload.Subtitle)
+	case models.NotificationKindAccountSubscription:
+		return NotifyUserSubscription(actor, payload.Content, payload.ContentTitle)
+	case models.NotificationKindTagSubscription:
+		var tag models.Tag
+		if err := database.C.Where("id = ?", payload.TargetID).First(&tag).Error; err != nil {
+			return err
+		}
+		return NotifyTagSubscription(tag, actor, payload.Content, payload.ContentTitle)
+	case models.NotificationKindCategorySubscription:
+		var category models.Category
+		if err := database.C.Where("id = ?", payload.TargetID).First(&category).Error; err != nil {
+			return err
+		}
+		return NotifyCategorySubscription(category, actor, payload.Content, payload.ContentTitle)
+	case models.NotificationKindPollClosed:
+		var post models.Post
+		if err := database.C.Where("id = ?", payload.PostID).First(&post).Error; err != nil {
+			return err
+		}
+		body, err := DecodePollBody(post)
+		if err != nil {
+			return err
+		}
+		return NotifyPollVoters(post, body)
+	}
+
+	return fmt.Errorf("unknown notification kind %s", item.Kind)
+}
+
+func sendNotification(id uint) error {
+	if !notificationRetry.Claim(&models.NotificationOutbox{}, id, models.NotificationOutboxPending) {
+		return nil
+	}
+
+	var item models.NotificationOutbox
+	if err := database.C.Where("id = ?", id).First(&item).Error; err != nil {
+		log.Error().Err(err).Uint("notification", id).Msg("Unable to get queued notification...")
+		return err
+	}
+
+	item.Attempts++
+	err := dispatchNotification(item)
+	if err == nil {
+		item.Error = ""
+		item.Status = models.NotificationOutboxSent
+		item.SentAt = lo.ToPtr(time.Now())
+		item.NextAttemptAt = nil
+	} else {
+		item.Error = err.Error()
+		// The records went away won't come back, retrying is useless
+		if errors.Is(err, gorm.ErrRecordNotFound) || item.Attempts >= notificationRetry.MaxAttempts() {
+			item.Status = models.NotificationOutboxDead
+			item.NextAttemptAt = nil
+		} else {
+			item.NextAttemptAt = lo.ToPtr(time.Now().Add(notificationRetry.Backoff(item.Attempts)))
+		}
+		log.Warn().Err(err).Uint("notification", item.ID).Int("attempts", item.Attempts).Msg("Unable to send notification...")
+	}
+
+	if err := database.C.Save(&item).Error; err != nil {
+		log.Error().Err(err).Uint("notification", item.ID).Msg("Unable to save queued notification...")
+	}
+
+	return err
+}
+
+// DoNotificationDispatch sends the due notifications in the outbox, it is safe to run
+// it concurrently since every notification is claimed before sending.
+func DoNotificationDispatch() {
+	start := time.Now()
+
+	var id []uint
+	if err := database.C.Model(&models.NotificationOutbox{}).
+		Where("status = ? AND next_attempt_at <= ?", models.NotificationOutboxPending, time.Now()).
+		Order("next_attempt_at ASC").
+		Limit(100).
+		Pluck("id", &id).Error; err != nil {
+		log.Error().Err(err).Msg("An error occurred when dispatching notifications...")
+		metrics.ObserveCronJob("notification_dispatch", start, 0, err)
+		return
+	}
+
+	var failed int
+	for _, item := range id {
+		if err := sendNotification(item); err != nil {
+			failed++
+		}
+	}
+
+	var err error
+	if failed > 0 {
+		err = fmt.Errorf("%d notifications failed to send", failed)
+	}
+	metrics.ObserveCronJob("notification_dispatch", start, int64(len(id)-failed), err)
+
+	if len(id) > 0 {
+		log.Debug().Int("count", len(id)).Int("failed", failed).Msg("Dispatched pending notifications.")
+	}
+}
+
+// DoNotificationOutboxCleanup removes the sent notifications older than the retention,
+// the dead ones are kept for the administrators to look into.
+func DoNotificationOutboxCleanup() {
+	start := time.Now()
+
+	retention := viper.GetDuration("notification.sent_retention")
+	if retention <= 0 {
+		retention = 7 * 24 * time.Hour
+	}
+
+	tx := database.C.Unscoped().
+		Where("status = ? AND sent_at < ?", models.NotificationOutboxSent, time.Now().Add(-retention)).
+		Delete(&models.NotificationOutbox{})
+	metrics.ObserveCronJob("notification_cleanup", start, tx.RowsAffected, tx.Error)
+
+	if tx.Error != nil {
+		log.Error().Err(tx.Error).Msg("An error occurred when cleaning up sent notifications...")
+	} else if tx.RowsAffected > 0 {
+		log.Debug().Int64("count", tx.RowsAffected).Msg("Cleaned up sent notifications.")
+	}
+}
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"git.solsynth.dev/hydrogen/dealer/pkg/hyper"
+	"git.solsynth.dev/hydrogen/dealer/pkg/proto"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/gap"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/metrics"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/rs/zerolog/log"
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+)
+
+func DecodePollBody(post models.Post) (models.PostPollBody, error) {
+	var body models.PostPollBody
+	if post.Type != models.PostTypePoll {
+		return body, fmt.Errorf("post #%d is not a poll", post.ID)
+	}
+
+	raw, _ := jsoniter.Marshal(post.Body)
+	if err := jsoniter.Unmarshal(raw, &body); err != nil {
+		return body, fmt.Errorf("unable to decode poll: %v", err)
+	}
+	return body, nil
+}
+
+func IsPollClosed(body models.PostPollBody) bool {
+	if body.ClosedAt != nil {
+		return true
+	}
+	return body.ExpiredAt != nil && body.ExpiredAt.Before(time.Now())
+}
+
+func VotePoll(user models.Account, post models.Post, choices []int) ([]models.PollVote, error) {
+	body, err := DecodePollBody(post)
+	if err != nil {
+		return nil, err
+	}
+
+	if IsPollClosed(body) {
+		return nil, fmt.Errorf("poll was closed")
+	}
+	choices = lo.Uniq(choices)
+	if len(choices) == 0 {
+		return nil, fmt.Errorf("you need choose at least one option")
+	} else if !body.IsMultiChoice && len(choices) > 1 {
+		return nil, fmt.Errorf("this poll only accept one option")
+	}
+
+	options := lo.Map(body.Options, func(item models.PostPollOption, index int) int {
+		return item.ID
+	})
+	votes := make([]models.PollVote, 0, len(choices))
+	for _, choice := range choices {
+		if !lo.Contains(options, choice) {
+			return nil, fmt.Errorf("option %d does not exist in this poll", choice)
+		}
+		votes = append(votes, models.PollVote{
+			PostID:    post.ID,
+			OptionID:  choice,
+			AccountID: user.ID,
+		})
+	}
+
+	err = database.C.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().
+			Where("post_id = ? AND account_id = ?", post.ID, user.ID).
+			Delete(&models.PollVote{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&votes).Error
+	})
+
+	return votes, err
+}
+
+func ClosePoll(post models.Post) (models.Post, error) {
+	body, err := DecodePollBody(post)
+	if err != nil {
+		return post, err
+	}
+
+	if body.ClosedAt != nil {
+		return post, fmt.Errorf("poll was already closed")
+	}
+
+	post.Body["closed_at"] = time.Now()
+	if err := database.C.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&post).Update("body", post.Body).Error; err != nil {
+			return err
+		}
+		return EnqueuePollNotification(tx, post)
+	}); err != nil {
+		return post, err
+	}
+
+	go DoNotificationDispatch()
+
+	return post, nil
+}
+
+func ListPollVotes(post models.Post) ([]models.PollVote, error) {
+	var votes []models.PollVote
+	if err := database.C.
+		Where("post_id = ?", post.ID).
+		Preload("Account").
+		Order("created_at DESC").
+		Find(&votes).Error; err != nil {
+		return votes, err
+	}
+
+	return votes, nil
+}
+
+// LoadPollMetrics fills the vote results of the polls among the posts, the
+// results only show up after the user voted or the poll was closed.
+func LoadPollMetrics(items []*models.Post, user *models.Account) error {
+	polls := lo.Filter(items, func(item *models.Post, index int) bool {
+		return item != nil && item.Type == models.PostTypePoll
+	})
+	if len(polls) == 0 {
+		return nil
+	}
+
+	idx := lo.Map(polls, func(item *models.Post, index int) uint {
+		return item.ID
+	})
+
+	var results []struct {
+		PostID   uint
+		OptionID int
+		Count    int64
+	}
+	if err := database.C.Model(&models.PollVote{}).
+		Select("post_id, option_id, COUNT(id) as count").
+		Where("post_id IN ?", idx).
+		Group("post_id, option_id").
+		Scan(&results).Error; err != nil {
+		return err
+	}
+
+	var voters []struct {
+		PostID uint
+		Count  int64
+	}
+	if err := database.C.Model(&models.PollVote{}).
+		Select("post_id, COUNT(DISTINCT account_id) as count").
+		Where("post_id IN ?", idx).
+		Group("post_id").
+		Scan(&voters).Error; err != nil {
+		return err
+	}
+
+	var choices []models.PollVote
+	if user != nil {
+		if err := database.C.
+			Where("post_id IN ? AND account_id = ?", idx, user.ID).
+			Find(&choices).Error; err != nil {
+			return err
+		}
+	}
+
+	for _, post := range polls {
+		post.Metric.PollChoices = lo.FilterMap(choices, func(item models.PollVote, index int) (int, bool) {
+			return item.OptionID, item.PostID == post.ID
+		})
+
+		body, err := DecodePollBody(*post)
+		if err != nil {
+			continue
+		}
+		isAuthor := user != nil && user.ID == post.AuthorID
+		if !isAuthor && len(post.Metric.PollChoices) == 0 && !IsPollClosed(body) {
+			continue
+		}
+
+		post.Metric.PollVoteList = make(map[int]int64)
+		for _, result := range results {
+			if result.PostID == post.ID {
+				post.Metric.PollVoteList[result.OptionID] = result.Count
+			}
+		}
+		for _, voter := range voters {
+			if voter.PostID == post.ID {
+				post.Metric.PollVoteCount = voter.Count
+			}
+		}
+	}
+
+	return nil
+}
+
+func NotifyPollVoters(post models.Post, body models.PostPollBody) error {
+	var voters []uint
+	if err := database.C.Model(&models.PollVote{}).
+		Where("post_id = ?", post.ID).
+		Distinct("account_id").
+		Pluck("account_id", &voters).Error; err != nil {
+		return fmt.Errorf("unable to get poll voters: %v", err)
+	}
+	if len(voters) == 0 {
+		return nil
+	}
+
+	nTitle := "Poll closed"
+	nSubtitle := "From the poll you voted"
+	nBody := TruncatePostContentShort(body.Content)
+	if body.Title != nil {
+		nBody = fmt.Sprintf("%s\n%s", *body.Title, nBody)
+	}
+
+	pc, err := gap.H.GetServiceGrpcConn(hyper.ServiceTypeAuthProvider)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	metrics.ObserveNotificationFanout("interactive.poll", len(voters))
+	ctx, done := observeGrpc(ctx, "Notifier", "NotifyUserBatch")
+	_, err = proto.NewNotifierClient(pc).NotifyUserBatch(ctx, &proto.NotifyUserBatchRequest{
+		UserId: lo.Map(voters, func(item uint, index int) uint64 {
+			return uint64(item)
+		}),
+		Notify: &proto.NotifyRequest{
+			Topic:    "interactive.poll",
+			Title:    nTitle,
+			Subtitle: &nSubtitle,
+			Body:     nBody,
+			Metadata: hyper.EncodeMap(map[string]any{
+				"related_post": TruncatePostContent(post),
+			}),
+			IsRealtime:  false,
+			IsForcePush: true,
+		},
+	})
+	done(err)
+
+	return err
+}
+
+func DoAutoPollClose() {
+	var posts []models.Post
+	if err := database.C.
+		Where("type = ?", models.PostTypePoll).
+		Where("body->>'closed_at' IS NULL").
+		Where("(body->>'expired_at')::timestamptz <= ?", time.Now()).
+		Find(&posts).Error; err != nil {
+		log.Error().Err(err).Msg("An error occurred when looking for expired polls...")
+		return
+	}
+
+	for _, post := range posts {
+		if _, err := ClosePoll(post); err != nil {
+			log.Error().Err(err).Uint("post", post.ID).Msg("An error occurred when closing expired poll...")
+		}
+	}
+
+	if len(posts) > 0 {
+		log.Debug().Int("count", len(posts)).Msg("Closed expired polls.")
+	}
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/samber/lo"
+	"gorm.io/datatypes"
+)
+
+// PostType describes a kind of post, the generic post endpoints bind the
+// request into the body returned by NewBody, then store it as the post body.
+type PostType struct {
+	Name string
+	// NewBody returns a pointer to an empty body of this type
+	NewBody func() any
+	// PrepareBody validates and fills the bound body before it is stored, optional
+	PrepareBody func(body any) error
+	// LanguageFields are the body fields used to detect the post language
+	LanguageFields []string
+	IsReplyable    bool
+	IsEditable     bool
+}
+
+var postTypes = map[string]PostType{
+	models.PostTypeStory: {
+		Name:           models.PostTypeStory,
+		NewBody:        func() any { return &models.PostStoryBody{} },
+		LanguageFields: []string{"title", "content"},
+		IsReplyable:    true,
+		IsEditable:     true,
+	},
+	models.PostTypeArticle: {
+		Name:           models.PostTypeArticle,
+		NewBody:        func() any { return &models.PostArticleBody{} },
+		LanguageFields: []string{"title", "content"},
+		IsEditable:     true,
+	},
+	models.PostTypePoll: {
+		Name:           models.PostTypePoll,
+		NewBody:        func() any { return &models.PostPollBody{} },
+		PrepareBody:    preparePollBody,
+		LanguageFields: []string{"title", "content"},
+	},
+}
+
+func RegisterPostType(kind PostType) {
+	postTypes[kind.Name] = kind
+}
+
+func GetPostType(name string) (PostType, error) {
+	if kind, ok := postTypes[name]; ok {
+		return kind, nil
+	}
+	return PostType{}, fmt.Errorf("unknown post type %s", name)
+}
+
+func EncodePostBody(kind PostType, body any) (datatypes.JSONMap, error) {
+	if kind.PrepareBody != nil {
+		if err := kind.PrepareBody(body); err != nil {
+			return nil, err
+		}
+	}
+
+	var bodyMapping map[string]any
+	rawBody, err := jsoniter.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	if err = jsoniter.Unmarshal(rawBody, &bodyMapping); err != nil {
+		return nil, err
+	}
+
+	return bodyMapping, nil
+}
+
+func DetectPostLanguage(kind PostType, body datatypes.JSONMap) (string, float64) {
+	var content []string
+	for _, field := range kind.LanguageFields {
+		if val, ok := body[field].(string); ok && len(val) > 0 {
+			content = append(content, val)
+		}
+	}
+	return DetectLanguageWithConfidence(strings.Join(content, "\n"))
+}
+
+func preparePollBody(body any) error {
+	poll := body.(*models.PostPollBody)
+	if poll.ExpiredAt != nil && poll.ExpiredAt.Before(time.Now()) {
+		return fmt.Errorf("poll expired time must be in the future")
+	}
+
+	poll.ClosedAt = nil
+	poll.Options = lo.Map(poll.Options, func(item models.PostPollOption, index int) models.PostPollOption {
+		item.ID = index
+		return item
+	})
+
+	return nil
+}
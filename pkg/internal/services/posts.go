@@ -1,10 +1,11 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"regexp"
-	"strconv"
 	"time"
 
 	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
@@ -12,45 +13,48 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/samber/lo"
 	"github.com/spf13/viper"
-	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
+// FilterPostWithUserContext keeps the posts the user can see, see the visibility
+// levels in models for the policy. Anonymous users only see the public posts.
 func FilterPostWithUserContext(tx *gorm.DB, user *models.Account) *gorm.DB {
 	if user == nil {
 		return tx.Where("visibility = ?", models.PostVisibilityAll)
 	}
 
-	const (
-		FriendsVisibility  = models.PostVisibilityFriends
-		SelectedVisibility = models.PostVisibilitySelected
-		FilteredVisibility = models.PostVisibilityFiltered
-		NoneVisibility     = models.PostVisibilityNone
-	)
+	// The relationships are looked up in the trace of the query
+	friends, err := ListAccountFriendIDs(tx.Statement.Context, *user)
+	if err != nil {
+		log.Warn().Err(err).Uint("user", user.ID).Msg("Unable to get friends, filtering posts without them...")
+	}
+	blocklist, err := ListAccountBlockedUserIDs(tx.Statement.Context, *user)
+	if err != nil {
+		if viper.GetBool("relationship.blocklist_fail_closed") {
+			// Refuse to list posts rather than leaking the blocked users' posts
+			_ = tx.AddError(err)
+			return tx
+		}
+		log.Warn().Err(err).Uint("user", user.ID).Msg("Unable to get blocklist, filtering posts without it...")
+	}
 
-	friends, _ := ListAccountFriends(*user)
-	allowlist := lo.Map(friends, func(item models.Account, index int) uint {
-		return item.ID
-	})
-	blocked, _ := ListAccountBlockedUsers(*user)
-	blocklist := lo.Map(blocked, func(item models.Account, index int) uint {
-		return item.ID
-	})
+	// The user lists are JSON arrays of numbers, check the containment instead of the keys
+	self := fmt.Sprintf("[%d]", user.ID)
 
-	tx = tx.Where(
-		"(visibility != ? OR (visibility != ? AND author_id IN ? AND author_id NOT IN ?) OR (visibility = ? AND ?) OR (visibility = ? AND NOT ?) OR author_id = ?)",
-		NoneVisibility,
-		FriendsVisibility,
-		allowlist,
-		blocklist,
-		SelectedVisibility,
-		datatypes.JSONQuery("visible_users").HasKey(strconv.Itoa(int(user.ID))),
-		FilteredVisibility,
-		datatypes.JSONQuery("invisible_users").HasKey(strconv.Itoa(int(user.ID))),
-		user.ID,
-	)
+	visible := database.C.
+		Where("visibility = ?", models.PostVisibilityAll).
+		Or("visibility = ? AND author_id IN ?", models.PostVisibilityFriends, friends).
+		Or("visibility = ? AND NOT COALESCE(invisible_users, '[]'::jsonb) @> ?::jsonb", models.PostVisibilityFiltered, self).
+		Or(
+			database.C.Where("visibility = ?", models.PostVisibilitySelected).
+				Where(database.C.Where("COALESCE(visible_users, '[]'::jsonb) @> ?::jsonb", self).Or(audiencePostsOf(user.ID))),
+		)
+	if len(blocklist) > 0 {
+		visible = database.C.Where(visible).Where("author_id NOT IN ?", blocklist)
+	}
+	visible = database.C.Where(visible).Where("author_id NOT IN (?)", mutedAccountsOf(user.ID))
 
-	return tx
+	return tx.Where(database.C.Where("author_id = ?", user.ID).Or(visible))
 }
 
 func FilterPostWithCategory(tx *gorm.DB, alias string) *gorm.DB {
@@ -75,6 +79,31 @@ func FilterPostWithRealm(tx *gorm.DB, id uint) *gorm.DB {
 	}
 }
 
+// FilterPostWithLanguages keeps the posts written or translated in the languages,
+// the posts in an unknown language are kept because the detection is not sure about them.
+// uncertainPostLanguage matches the posts whose language was guessed with a confidence
+// too low to trust, the posts detected before the confidence was stored have zero.
+const uncertainPostLanguage = "(NOT is_language_specified AND language_confidence < ?)"
+
+func FilterPostWithLanguages(tx *gorm.DB, languages []string) *gorm.DB {
+	prefix := viper.GetString("database.prefix")
+	return tx.Where(
+		fmt.Sprintf("language IN ? OR language = ? OR %s OR EXISTS (SELECT 1 FROM %spost_translations WHERE post_id = %sposts.id AND language IN ?)", uncertainPostLanguage, prefix, prefix),
+		languages,
+		UnknownLanguage,
+		viper.GetFloat64("language.min_confidence"),
+		languages,
+	)
+}
+
+func FilterPostWithoutLanguages(tx *gorm.DB, languages []string) *gorm.DB {
+	return tx.Where(
+		fmt.Sprintf("language NOT IN ? OR %s", uncertainPostLanguage),
+		languages,
+		viper.GetFloat64("language.min_confidence"),
+	)
+}
+
 func FilterPostReply(tx *gorm.DB, replyTo ...uint) *gorm.DB {
 	if len(replyTo) > 0 && replyTo[0] > 0 {
 		return tx.Where("reply_id = ?", replyTo[0])
@@ -97,6 +126,13 @@ func FilterPostDraft(tx *gorm.DB) *gorm.DB {
 	return tx.Where("is_draft = ? OR is_draft IS NULL", false)
 }
 
+// FilterPostPublic keeps the published posts everyone can see, used by the anonymous exports.
+func FilterPostPublic(tx *gorm.DB) *gorm.DB {
+	tx = FilterPostDraft(tx)
+	tx = FilterPostWithPublishedAt(tx, time.Now())
+	return tx.Where("visibility = ?", models.PostVisibilityAll)
+}
+
 func FilterPostWithFuzzySearch(tx *gorm.DB, probe string) *gorm.DB {
 	probe = "%" + probe + "%"
 	return tx.
@@ -109,6 +145,7 @@ func PreloadGeneral(tx *gorm.DB) *gorm.DB {
 	return tx.
 		Preload("Tags").
 		Preload("Categories").
+		Preload("Links").
 		Preload("Realm").
 		Preload("Author").
 		Preload("ReplyTo").
@@ -133,7 +170,9 @@ func GetPost(tx *gorm.DB, id uint, ignoreLimitation ...bool) (models.Post, error
 		return item, err
 	}
 
-	return item, nil
+	err := redactPostRelatives(tx, &item)
+
+	return item, err
 }
 
 func GetPostByAlias(tx *gorm.DB, alias, area string, ignoreLimitation ...bool) (models.Post, error) {
@@ -149,7 +188,9 @@ func GetPostByAlias(tx *gorm.DB, alias, area string, ignoreLimitation ...bool) (
 		return item, err
 	}
 
-	return item, nil
+	err := redactPostRelatives(tx, &item)
+
+	return item, err
 }
 
 func CountPost(tx *gorm.DB) (int64, error) {
@@ -161,9 +202,10 @@ func CountPost(tx *gorm.DB) (int64, error) {
 	return count, nil
 }
 
-func CountPostReply(id uint) int64 {
+// CountPostReply counts the replies the user can read, the user is nil for anonymous visitors.
+func CountPostReply(id uint, user *models.Account) int64 {
 	var count int64
-	if err := database.C.Model(&models.Post{}).
+	if err := FilterPostReadable(database.C.Model(&models.Post{}), user).
 		Where("reply_id = ?", id).
 		Count(&count).Error; err != nil {
 		return 0
@@ -196,6 +238,10 @@ func ListPost(tx *gorm.DB, take int, offset int, order any, noReact ...bool) ([]
 		return items, err
 	}
 
+	if err := redactPostRelatives(tx, items...); err != nil {
+		return items, err
+	}
+
 	idx := lo.Map(items, func(item *models.Post, index int) uint {
 		return item.ID
 	})
@@ -226,7 +272,10 @@ func ListPost(tx *gorm.DB, take int, offset int, order any, noReact ...bool) ([]
 			Count  int64
 		}
 
-		if err := database.C.Model(&models.Post{}).
+		// Only count the replies the viewer can read, the query without a viewer counts the public ones
+		viewer, _ := tx.Get(postViewerKey)
+		user, _ := viewer.(*models.Account)
+		if err := FilterPostReadable(database.C.WithContext(tx.Statement.Context).Model(&models.Post{}), user).
 			Select("reply_id as post_id, COUNT(id) as count").
 			Where("reply_id IN (?)", idx).
 			Group("post_id").
@@ -253,6 +302,10 @@ func ListPost(tx *gorm.DB, take int, offset int, order any, noReact ...bool) ([]
 		}
 	}
 
+	for _, item := range items {
+		item.Metric.ViewCount = item.TotalViews
+	}
+
 	return items, nil
 }
 
@@ -316,9 +369,17 @@ func NewPost(user models.Account, item models.Post) (models.Post, error) {
 		return item, err
 	}
 
+	item, err = RenderPostContent(item)
+	if err != nil {
+		return item, fmt.Errorf("unable to render post content: %v", err)
+	}
+	if item.Type == models.PostTypeArticle {
+		item = AnalyzeArticle(item)
+	}
+
 	if item.RealmID != nil {
 		log.Debug().Uint("id", *item.RealmID).Msg("Looking for post author realm...")
-		member, err := GetRealmMember(*item.RealmID, user.ID)
+		member, err := GetRealmMember(context.Background(), *item.RealmID, user.ID)
 		if err != nil {
 			return item, fmt.Errorf("you aren't a part of that realm: %v", err)
 		} else if !item.Realm.IsCommunity && member.PowerLevel < 25 {
@@ -326,53 +387,67 @@ func NewPost(user models.Account, item models.Post) (models.Post, error) {
 		}
 	}
 
-	log.Debug().Msg("Saving post record into database...")
-	if err := database.C.Save(&item).Error; err != nil {
-		return item, err
-	}
-
-	// Notify the original poster its post has been replied
+	var op *models.Post
 	if item.ReplyID != nil {
-		var op models.Post
+		var replied models.Post
 		if err := database.C.
 			Where("id = ?", item.ReplyID).
 			Preload("Author").
-			First(&op).Error; err == nil {
-			if op.Author.ID != user.ID {
-				log.Debug().Uint("user", op.AuthorID).Msg("Notifying the original poster their post got replied...")
-				err = NotifyPosterAccount(
-					op.Author,
-					op,
-					"Post got replied",
-					fmt.Sprintf("%s (%s) replied your post (#%d).", user.Nick, user.Name, op.ID),
-					lo.ToPtr(fmt.Sprintf("%s replied you", user.Nick)),
-				)
-				if err != nil {
-					log.Error().Err(err).Msg("An error occurred when notifying user...")
-				}
-			}
+			First(&replied).Error; err == nil {
+			op = &replied
 		}
 	}
 
-	// Notify the subscriptions
-	if content, ok := item.Body["content"].(string); ok {
-		var title *string
-		title, _ = item.Body["title"].(*string)
-		go func() {
-			if err := NotifyUserSubscription(user, content, title); err != nil {
-				log.Error().Err(err).Msg("An error occurred when notifying subscriptions user by user...")
-			}
-			for _, tag := range item.Tags {
-				if err := NotifyTagSubscription(tag, user, content, title); err != nil {
-					log.Error().Err(err).Msg("An error occurred when notifying subscriptions user by tag...")
-				}
-			}
-			for _, category := range item.Categories {
-				if err := NotifyCategorySubscription(category, user, content, title); err != nil {
-					log.Error().Err(err).Msg("An error occurred when notifying subscriptions user by category...")
-				}
+	// The notifications are queued along with the post, they won't get lost when the notifier is down
+	log.Debug().Msg("Saving post record into database...")
+	if err := database.C.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&item).Error; err != nil {
+			return err
+		}
+
+		// Notify the original poster its post has been replied
+		if op != nil && op.Author.ID != user.ID && !IsAccountMuted(op.Author, user) {
+			log.Debug().Uint("user", op.AuthorID).Msg("Notifying the original poster their post got replied...")
+			if err := EnqueuePosterNotification(
+				tx,
+				op.Author,
+				*op,
+				"Post got replied",
+				fmt.Sprintf("%s (%s) replied your post (#%d).", user.Nick, user.Name, op.ID),
+				lo.ToPtr(fmt.Sprintf("%s replied you", user.Nick)),
+			); err != nil {
+				return err
 			}
-		}()
+		}
+
+		// Notify the subscriptions
+		if _, ok := item.Body["content"].(string); ok {
+			return EnqueueSubscriptionNotifications(tx, user, item)
+		}
+		return nil
+	}); err != nil {
+		return item, err
+	}
+
+	go DoNotificationDispatch()
+	go LinkPostPreviews(item)
+
+	if op != nil {
+		go PublishPostEvent(PostEventReplied, *op)
+		if !item.IsDraft && item.Visibility == models.PostVisibilityAll {
+			go EmitPostWebhookEvent(models.WebhookEventPostReplied, *op, map[string]any{
+				"post":     item,
+				"reply_to": *op,
+			})
+		}
+	}
+
+	go PublishPostEvent(PostEventCreated, item)
+	go EmitPostWebhookEvent(models.WebhookEventPostCreated, item, item)
+
+	if IsFederationEnabled() {
+		item.Author = user
+		go FederatePost(item)
 	}
 
 	log.Debug().Dur("elapsed", time.Since(start)).Msg("The post is posted.")
@@ -402,13 +477,37 @@ func EditPost(item models.Post) (models.Post, error) {
 		return item, err
 	}
 
-	err = database.C.Save(&item).Error
+	item, err = RenderPostContent(item)
+	if err != nil {
+		return item, fmt.Errorf("unable to render post content: %v", err)
+	}
+	if item.Type == models.PostTypeArticle {
+		item = AnalyzeArticle(item)
+	}
 
-	return item, err
+	if err = database.C.Save(&item).Error; err != nil {
+		return item, err
+	}
+	if err = database.C.Model(&item).Association("AudienceLists").Replace(item.AudienceLists); err != nil {
+		return item, err
+	}
+
+	go LinkPostPreviews(item)
+	go PublishPostEvent(PostEventEdited, item)
+	go EmitPostWebhookEvent(models.WebhookEventPostEdited, item, item)
+
+	return item, nil
 }
 
 func DeletePost(item models.Post) error {
-	return database.C.Delete(&item).Error
+	if err := database.C.Delete(&item).Error; err != nil {
+		return err
+	}
+
+	go PublishPostEvent(PostEventDeleted, item)
+	go EmitPostWebhookEvent(models.WebhookEventPostDeleted, item, item)
+
+	return nil
 }
 
 func ReactPost(user models.Account, reaction models.Reaction) (bool, models.Reaction, error) {
@@ -422,20 +521,30 @@ func ReactPost(user models.Account, reaction models.Reaction) (bool, models.Reac
 
 	if err := database.C.Where(reaction).First(&reaction).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			if op.Author.ID != user.ID {
-				err = NotifyPosterAccount(
-					op.Author,
-					op,
-					"Post got reacted",
-					fmt.Sprintf("%s (%s) reacted your post a %s.", user.Nick, user.Name, reaction.Symbol),
-					lo.ToPtr(fmt.Sprintf("%s reacted you", user.Nick)),
-				)
-				if err != nil {
-					log.Error().Err(err).Msg("An error occurred when notifying user...")
+			err = database.C.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Save(&reaction).Error; err != nil {
+					return err
+				}
+				if op.Author.ID != user.ID && !IsAccountMuted(op.Author, user) {
+					return EnqueuePosterNotification(
+						tx,
+						op.Author,
+						op,
+						"Post got reacted",
+						fmt.Sprintf("%s (%s) reacted your post a %s.", user.Nick, user.Name, reaction.Symbol),
+						lo.ToPtr(fmt.Sprintf("%s reacted you", user.Nick)),
+					)
 				}
+				return nil
+			})
+			if err == nil {
+				go DoNotificationDispatch()
+				go PublishPostEvent(PostEventReacted, op)
+				go EmitPostWebhookEvent(models.WebhookEventReactionAdded, op, map[string]any{
+					"post_id":  op.ID,
+					"reaction": reaction,
+				})
 			}
-
-			err = database.C.Save(&reaction).Error
 			if err == nil && reaction.Attitude != models.AttitudeNeutral {
 				_ = ModifyPosterVoteCount(op.Author, reaction.Attitude == models.AttitudePositive, 1)
 
@@ -453,6 +562,9 @@ func ReactPost(user models.Account, reaction models.Reaction) (bool, models.Reac
 		}
 	} else {
 		err = database.C.Delete(&reaction).Error
+		if err == nil {
+			go PublishPostEvent(PostEventReacted, op)
+		}
 		if err == nil && reaction.Attitude != models.AttitudeNeutral {
 			_ = ModifyPosterVoteCount(op.Author, reaction.Attitude == models.AttitudePositive, -1)
 
@@ -483,20 +595,42 @@ func PinPost(post models.Post) (bool, error) {
 
 const TruncatePostContentThreshold = 160
 
+// TruncatePostContent replaces the content with the plaintext of it, cut down when it is too long.
 func TruncatePostContent(post models.Post) models.Post {
 	if post.Body["content"] != nil {
-		if val, ok := post.Body["content"].(string); ok {
-			length := TruncatePostContentThreshold
-			if len([]rune(val)) >= length {
-				post.Body["content"] = string([]rune(val)[:length]) + "..."
-				post.Body["content_truncated"] = true
-			}
+		EnsurePostRendered(&post)
+		post.Body = maps.Clone(post.Body)
+		val := []rune(post.PlaintextContent)
+		if length := TruncatePostContentThreshold; len(val) >= length {
+			post.Body["content"] = string(val[:length]) + "..."
+			post.Body["content_truncated"] = true
+		} else {
+			post.Body["content"] = string(val)
 		}
 	}
 
 	return post
 }
 
+// TruncatePostsContent truncates the content of the listed posts in place.
+func TruncatePostsContent(items []*models.Post) {
+	for _, item := range items {
+		if item != nil {
+			*item = TruncatePostContent(*item)
+		}
+	}
+}
+
+func RenderPostHTML(post models.Post) models.Post {
+	if post.Body["content"] != nil {
+		EnsurePostRendered(&post)
+		post.Body = maps.Clone(post.Body)
+		post.Body["content_html"] = post.RenderedContent
+	}
+
+	return post
+}
+
 const TruncatePostContentShortThreshold = 80
 
 func TruncatePostContentShort(content string) string {
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"git.solsynth.dev/hydrogen/dealer/pkg/hyper"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	"github.com/samber/lo"
+	"github.com/spf13/viper"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+func TestFilterPostWithUserContext(t *testing.T) {
+	type world struct {
+		author, viewer models.Account
+	}
+
+	tests := []struct {
+		name       string
+		visibility models.PostVisibilityLevel
+		anonymous  bool
+		// prepare sets up the relationships and the post fields of the case
+		prepare func(t *testing.T, w world, post *models.Post) (friends, blocklist []uint)
+		want    bool
+	}{
+		{name: "public to anonymous", visibility: models.PostVisibilityAll, anonymous: true, want: true},
+		{name: "friends to anonymous", visibility: models.PostVisibilityFriends, anonymous: true, want: false},
+		{name: "filtered to anonymous", visibility: models.PostVisibilityFiltered, anonymous: true, want: false},
+		{name: "selected to anonymous", visibility: models.PostVisibilitySelected, anonymous: true, want: false},
+		{name: "private to anonymous", visibility: models.PostVisibilityNone, anonymous: true, want: false},
+
+		{name: "public to user", visibility: models.PostVisibilityAll, want: true},
+		{
+			name:       "friends to friend",
+			visibility: models.PostVisibilityFriends,
+			prepare: func(t *testing.T, w world, post *models.Post) ([]uint, []uint) {
+				return []uint{w.author.ID}, nil
+			},
+			want: true,
+		},
+		{name: "friends to stranger", visibility: models.PostVisibilityFriends, want: false},
+		{name: "filtered to user", visibility: models.PostVisibilityFiltered, want: true},
+		{
+			name:       "filtered to invisible user",
+			visibility: models.PostVisibilityFiltered,
+			prepare: func(t *testing.T, w world, post *models.Post) ([]uint, []uint) {
+				post.InvisibleUsers = datatypes.NewJSONSlice([]uint{w.viewer.ID})
+				return nil, nil
+			},
+			want: false,
+		},
+		{
+			name:       "selected to visible user",
+			visibility: models.PostVisibilitySelected,
+			prepare: func(t *testing.T, w world, post *models.Post) ([]uint, []uint) {
+				post.VisibleUsers = datatypes.NewJSONSlice([]uint{w.viewer.ID})
+				return nil, nil
+			},
+			want: true,
+		},
+		{
+			name:       "selected to audience member",
+			visibility: models.PostVisibilitySelected,
+			prepare: func(t *testing.T, w world, post *models.Post) ([]uint, []uint) {
+				list := models.AudienceList{
+					Name:      "Close friends",
+					AccountID: w.author.ID,
+					Members:   []models.AudienceListMember{{AccountID: w.viewer.ID}},
+				}
+				if err := database.C.Create(&list).Error; err != nil {
+					t.Fatal(err)
+				}
+				post.AudienceLists = []models.AudienceList{list}
+				return nil, nil
+			},
+			want: true,
+		},
+		{name: "selected to stranger", visibility: models.PostVisibilitySelected, want: false},
+		{name: "private to user", visibility: models.PostVisibilityNone, want: false},
+
+		{
+			name:       "public from blocked author",
+			visibility: models.PostVisibilityAll,
+			prepare: func(t *testing.T, w world, post *models.Post) ([]uint, []uint) {
+				return nil, []uint{w.author.ID}
+			},
+			want: false,
+		},
+		{
+			name:       "friends from blocked friend",
+			visibility: models.PostVisibilityFriends,
+			prepare: func(t *testing.T, w world, post *models.Post) ([]uint, []uint) {
+				return []uint{w.author.ID}, []uint{w.author.ID}
+			},
+			want: false,
+		},
+		{
+			name:       "public from muted author",
+			visibility: models.PostVisibilityAll,
+			prepare: func(t *testing.T, w world, post *models.Post) ([]uint, []uint) {
+				muteAccount(t, w.viewer, w.author, nil)
+				return nil, nil
+			},
+			want: false,
+		},
+		{
+			name:       "public from expired mute",
+			visibility: models.PostVisibilityAll,
+			prepare: func(t *testing.T, w world, post *models.Post) ([]uint, []uint) {
+				muteAccount(t, w.viewer, w.author, lo.ToPtr(time.Now().Add(-time.Hour)))
+				return nil, nil
+			},
+			want: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			requireDatabase(t)
+
+			w := world{author: createTestAccount(t, "author"), viewer: createTestAccount(t, "viewer")}
+			post := models.Post{
+				Type:       models.PostTypeStory,
+				Body:       datatypes.JSONMap{"content": "Hello, world"},
+				Visibility: tc.visibility,
+				AuthorID:   w.author.ID,
+			}
+
+			var friends, blocklist []uint
+			if tc.prepare != nil {
+				friends, blocklist = tc.prepare(t, w, &post)
+			}
+			// Seed the caches so the relationships are not fetched from the auth provider
+			friendCache.Set(w.viewer.ID, friends)
+			blocklistCache.Set(w.viewer.ID, blocklist)
+
+			if err := database.C.Create(&post).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			viewer := lo.Ternary(tc.anonymous, nil, &w.viewer)
+			var count int64
+			if err := FilterPostWithUserContext(database.C.Model(&models.Post{}), viewer).
+				Where("id = ?", post.ID).
+				Count(&count).Error; err != nil {
+				t.Fatal(err)
+			}
+			if got := count > 0; got != tc.want {
+				t.Errorf("visible = %v, want %v", got, tc.want)
+			}
+
+			// The authors always see their own posts, whatever the visibility is
+			if err := FilterPostWithUserContext(database.C.Model(&models.Post{}), &w.author).
+				Where("id = ?", post.ID).
+				Count(&count).Error; err != nil {
+				t.Fatal(err)
+			} else if count == 0 {
+				t.Errorf("post is invisible to the author")
+			}
+		})
+	}
+}
+
+func createTestAccount(t *testing.T, name string) models.Account {
+	t.Helper()
+	account := models.Account{BaseUser: hyper.BaseUser{Name: fmt.Sprintf("%s-%d", name, time.Now().UnixNano())}}
+	if err := database.C.Create(&account).Error; err != nil {
+		t.Fatal(err)
+	}
+	friendCache.Set(account.ID, nil)
+	blocklistCache.Set(account.ID, nil)
+	return account
+}
+
+func muteAccount(t *testing.T, user, target models.Account, expiredAt *time.Time) {
+	t.Helper()
+	mute := models.AccountMute{AccountID: user.ID, MutedID: target.ID, ExpiredAt: expiredAt}
+	if err := database.C.Create(&mute).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTruncatePostsContent(t *testing.T) {
+	long := strings.Repeat("word ", TruncatePostContentThreshold)
+	items := []*models.Post{
+		{Body: datatypes.JSONMap{"content": "**short**"}},
+		{Body: datatypes.JSONMap{"content": long}},
+		nil,
+	}
+	shared := items[1].Body
+
+	TruncatePostsContent(items)
+
+	if content := items[0].Body["content"]; content != "short" {
+		t.Errorf("expected the short content to be replaced with the plaintext, got %q", content)
+	}
+	if items[0].Body["content_truncated"] != nil {
+		t.Errorf("expected the short content not to be truncated")
+	}
+	if items[1].Body["content_truncated"] != true {
+		t.Errorf("expected the long content to be truncated")
+	}
+	if content, _ := items[1].Body["content"].(string); len([]rune(content)) != TruncatePostContentThreshold+3 {
+		t.Errorf("expected the content cut down to %d characters, got %d", TruncatePostContentThreshold, len([]rune(content)))
+	}
+	if shared["content"] != long || shared["content_truncated"] != nil {
+		t.Errorf("expected the original body to be left untouched")
+	}
+}
+
+func TestFilterPostLanguages(t *testing.T) {
+	requireDatabase(t)
+
+	prev := viper.Get("language.min_confidence")
+	viper.Set("language.min_confidence", 0.5)
+	t.Cleanup(func() { viper.Set("language.min_confidence", prev) })
+
+	author := createTestAccount(t, "author")
+	create := func(language string, confidence float64, specified bool) models.Post {
+		post := models.Post{
+			Type:                models.PostTypeStory,
+			Body:                datatypes.JSONMap{"content": "Hello, world"},
+			Language:            language,
+			LanguageConfidence:  confidence,
+			IsLanguageSpecified: specified,
+			AuthorID:            author.ID,
+		}
+		if err := database.C.Create(&post).Error; err != nil {
+			t.Fatal(err)
+		}
+		return post
+	}
+	detected := create("en", 0.9, false)
+	legacy := create("fr", 0, false)
+	create("de", 1, true)
+
+	list := func(filter func(tx *gorm.DB) *gorm.DB) []uint {
+		var id []uint
+		if err := filter(database.C.Model(&models.Post{}).Where("author_id = ?", author.ID)).
+			Order("id ASC").
+			Pluck("id", &id).Error; err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+
+	want := []uint{detected.ID, legacy.ID}
+	if got := list(func(tx *gorm.DB) *gorm.DB { return FilterPostWithLanguages(tx, []string{"en"}) }); !slices.Equal(got, want) {
+		t.Errorf("with languages = %v, want %v", got, want)
+	}
+	if got := list(func(tx *gorm.DB) *gorm.DB { return FilterPostWithoutLanguages(tx, []string{"fr", "de"}) }); !slices.Equal(got, want) {
+		t.Errorf("without languages = %v, want %v", got, want)
+	}
+}
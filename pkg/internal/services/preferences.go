@@ -0,0 +1,56 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+)
+
+func GetLanguagePreference(user models.Account) (models.LanguagePreference, error) {
+	pref := models.LanguagePreference{AccountID: user.ID}
+	if err := database.C.Where("account_id = ?", user.ID).First(&pref).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return pref, nil
+		}
+		return pref, err
+	}
+
+	return pref, nil
+}
+
+func SetLanguagePreference(user models.Account, preferred, excluded []string) (models.LanguagePreference, error) {
+	normalize := func(languages []string) ([]string, error) {
+		var out []string
+		for _, item := range languages {
+			lang := NormalizeLanguage(item)
+			if len(lang) == 0 {
+				return nil, fmt.Errorf("unknown language %s", item)
+			}
+			out = append(out, lang)
+		}
+		return lo.Uniq(out), nil
+	}
+
+	pref, err := GetLanguagePreference(user)
+	if err != nil {
+		return pref, err
+	}
+
+	if pref.Preferred, err = normalize(preferred); err != nil {
+		return pref, err
+	}
+	if pref.Excluded, err = normalize(excluded); err != nil {
+		return pref, err
+	}
+	if len(lo.Intersect(pref.Preferred, pref.Excluded)) > 0 {
+		return pref, fmt.Errorf("a language cannot be preferred and excluded at the same time")
+	}
+
+	err = database.C.Save(&pref).Error
+
+	return pref, err
+}
@@ -1,13 +1,14 @@
 package services
 
 import (
+	"context"
 	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
 	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
 	"github.com/gofiber/fiber/v2"
 )
 
 func GetPublisher(alias string) (any, error) {
-	realm, err := GetRealmWithAlias(alias)
+	realm, err := GetRealmWithAlias(context.Background(), alias)
 	if err == nil {
 		return fiber.Map{
 			"type": "realm",
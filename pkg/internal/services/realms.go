@@ -2,6 +2,8 @@ package services
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"git.solsynth.dev/hydrogen/dealer/pkg/hyper"
 	"git.solsynth.dev/hydrogen/dealer/pkg/proto"
@@ -10,43 +12,112 @@ import (
 	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
 	"github.com/samber/lo"
 	"github.com/spf13/viper"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
-func GetRealmWithID(id uint) (models.Realm, error) {
+var (
+	realmCache      = newTTLCache[uint, models.Realm]("realm.ttl", 5*time.Minute)
+	realmAliasCache = newTTLCache[string, uint]("realm.ttl", 5*time.Minute)
+	// realmMissCache remembers the aliases without a realm, publishers share the namespace with accounts
+	realmMissCache = newTTLCache[string, error]("realm.ttl", 5*time.Minute)
+)
+
+func lookupRealm(ctx context.Context, request *proto.LookupRealmRequest) (models.Realm, error) {
 	var realm models.Realm
 	pc, err := gap.H.GetServiceGrpcConn(hyper.ServiceTypeAuthProvider)
 	if err != nil {
 		return realm, err
 	}
-	response, err := proto.NewRealmClient(pc).GetRealm(context.Background(), &proto.LookupRealmRequest{
-		Id: lo.ToPtr(uint64(id)),
-	})
+	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+	ctx, done := observeGrpc(ctx, "Realm", "GetRealm")
+	response, err := proto.NewRealmClient(pc).GetRealm(ctx, request)
+	done(err)
 	if err != nil {
 		return realm, err
 	}
 	prefix := viper.GetString("database.prefix")
 	rm, err := hyper.LinkRealm(database.C, prefix+"realms", response)
-	return models.Realm{BaseRealm: rm}, err
+	if err != nil {
+		return realm, err
+	}
+
+	realm = models.Realm{BaseRealm: rm}
+	realmCache.Set(realm.ID, realm)
+	realmAliasCache.Set(realm.Alias, realm.ID)
+	realmMissCache.Delete(realm.Alias)
+
+	return realm, nil
 }
 
-func GetRealmWithAlias(alias string) (models.Realm, error) {
-	var realm models.Realm
-	pc, err := gap.H.GetServiceGrpcConn(hyper.ServiceTypeAuthProvider)
+func GetRealmWithID(ctx context.Context, id uint) (models.Realm, error) {
+	if realm, ok := realmCache.Get(id); ok {
+		return realm, nil
+	}
+
+	realm, err := lookupRealm(ctx, &proto.LookupRealmRequest{
+		Id: lo.ToPtr(uint64(id)),
+	})
 	if err != nil {
-		return realm, err
+		if stale, ok := realmCache.GetStale(id); ok {
+			return stale, nil
+		}
 	}
-	response, err := proto.NewRealmClient(pc).GetRealm(context.Background(), &proto.LookupRealmRequest{
+	return realm, err
+}
+
+func GetRealmWithAlias(ctx context.Context, alias string) (models.Realm, error) {
+	if id, ok := realmAliasCache.Get(alias); ok {
+		if realm, ok := realmCache.Get(id); ok {
+			return realm, nil
+		}
+	}
+
+	if err, ok := realmMissCache.Get(alias); ok {
+		return models.Realm{}, err
+	}
+
+	realm, err := lookupRealm(ctx, &proto.LookupRealmRequest{
 		Alias: &alias,
 	})
-	if err != nil {
-		return realm, err
+	if status.Code(err) == codes.NotFound {
+		realmMissCache.Set(alias, err)
+	} else if err != nil {
+		if id, ok := realmAliasCache.GetStale(alias); ok {
+			if stale, ok := realmCache.GetStale(id); ok {
+				return stale, nil
+			}
+		}
+	}
+	return realm, err
+}
+
+// InvalidateRealm drops the realm from the cache, the next lookup gets it
+// from the auth provider again.
+func InvalidateRealm(id uint) {
+	if realm, ok := realmCache.GetStale(id); ok {
+		realmAliasCache.Delete(realm.Alias)
+	}
+	realmCache.Delete(id)
+}
+
+func InvalidateRealmWithAlias(alias string) {
+	if id, ok := realmAliasCache.GetStale(alias); ok {
+		realmCache.Delete(id)
+	}
+	realmAliasCache.Delete(alias)
+	realmMissCache.Delete(alias)
+}
+
+func GetRealmCacheStats() map[string]CacheStats {
+	return map[string]CacheStats{
+		"realms":        realmCache.Stats(),
+		"realm_aliases": realmAliasCache.Stats(),
 	}
-	prefix := viper.GetString("database.prefix")
-	rm, err := hyper.LinkRealm(database.C, prefix+"realms", response)
-	return models.Realm{BaseRealm: rm}, err
 }
 
-func GetRealmMember(realmId uint, userId uint) (*proto.RealmMemberInfo, error) {
+func GetRealmMember(ctx context.Context, realmId uint, userId uint) (*proto.RealmMemberInfo, error) {
 	var realm models.Realm
 	if err := database.C.Where("id = ?", realmId).First(&realm).Error; err != nil {
 		return nil, err
@@ -55,13 +126,38 @@ func GetRealmMember(realmId uint, userId uint) (*proto.RealmMemberInfo, error) {
 	if err != nil {
 		return nil, err
 	}
-	response, err := proto.NewRealmClient(pc).GetRealmMember(context.Background(), &proto.RealmMemberLookupRequest{
+	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+	ctx, done := observeGrpc(ctx, "Realm", "GetRealmMember")
+	response, err := proto.NewRealmClient(pc).GetRealmMember(ctx, &proto.RealmMemberLookupRequest{
 		RealmId: lo.ToPtr(uint64(realm.ID)),
 		UserId:  lo.ToPtr(uint64(userId)),
 	})
+	done(err)
 	if err != nil {
 		return nil, err
 	} else {
 		return response, nil
 	}
 }
+
+func GetPublicRealmWithAlias(ctx context.Context, alias string) (models.Realm, error) {
+	realm, err := GetRealmWithAlias(ctx, alias)
+	if err != nil {
+		return realm, err
+	} else if !realm.IsPublic {
+		return realm, fmt.Errorf("realm is not public")
+	}
+	return realm, nil
+}
+
+// EnsureRealmPowerLevel checks the user is a member of the realm with the power level at least the level.
+func EnsureRealmPowerLevel(ctx context.Context, realmId uint, user models.Account, level int32, action string) error {
+	member, err := GetRealmMember(ctx, realmId, user.ID)
+	if err != nil {
+		return fmt.Errorf("you aren't a part of that realm: %v", err)
+	} else if member.PowerLevel < level {
+		return fmt.Errorf("you need has power level above %d of the realm to %s", level, action)
+	}
+	return nil
+}
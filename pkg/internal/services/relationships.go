@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"git.solsynth.dev/hydrogen/dealer/pkg/hyper"
+	"git.solsynth.dev/hydrogen/dealer/pkg/proto"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/gap"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	"github.com/rs/zerolog/log"
+	"github.com/samber/lo"
+	"golang.org/x/sync/singleflight"
+)
+
+var (
+	friendCache    = newTTLCache[uint, []uint]("relationship.ttl", time.Minute)
+	blocklistCache = newTTLCache[uint, []uint]("relationship.ttl", time.Minute)
+
+	// relativesFlight merges the concurrent fetches of the same account on cache miss
+	relativesFlight singleflight.Group
+)
+
+func relativesFlightKey(id uint, isBlocklist bool) string {
+	return fmt.Sprintf("%s:%d", lo.Ternary(isBlocklist, "blocklist", "friends"), id)
+}
+
+func fetchAccountRelatives(ctx context.Context, user models.Account, isBlocklist bool) ([]uint, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	pc, err := gap.H.GetServiceGrpcConn(hyper.ServiceTypeAuthProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	request := &proto.ListUserRelativeRequest{
+		UserId:    uint64(user.ID),
+		IsRelated: true,
+	}
+	list, method := proto.NewAuthClient(pc).ListUserFriends, "ListUserFriends"
+	if isBlocklist {
+		list, method = proto.NewAuthClient(pc).ListUserBlocklist, "ListUserBlocklist"
+	}
+	ctx, done := observeGrpc(ctx, "Auth", method)
+	result, err := list(ctx, request)
+	done(err)
+	if err != nil {
+		return nil, err
+	}
+
+	return lo.Map(result.Data, func(item *proto.SimpleUserInfo, index int) uint {
+		return uint(item.Id)
+	}), nil
+}
+
+// listCachedRelatives looks up the cache first, the stale entry is used when
+// the auth provider is unavailable.
+func listCachedRelatives(ctx context.Context, cache *ttlCache[uint, []uint], user models.Account, isBlocklist bool) ([]uint, error) {
+	if out, ok := cache.Get(user.ID); ok {
+		return out, nil
+	}
+
+	// The fetch is shared with the other callers, so it shouldn't be canceled with the first one
+	result, err, _ := relativesFlight.Do(relativesFlightKey(user.ID, isBlocklist), func() (any, error) {
+		out, err := fetchAccountRelatives(context.WithoutCancel(ctx), user, isBlocklist)
+		if err == nil {
+			cache.Set(user.ID, out)
+		}
+		return out, err
+	})
+	if err != nil {
+		if stale, ok := cache.GetStale(user.ID); ok {
+			log.Warn().Err(err).Uint("user", user.ID).Msg("Unable to refresh account relationships, using the stale one...")
+			return stale, nil
+		}
+		return nil, err
+	}
+
+	return result.([]uint), nil
+}
+
+func ListAccountFriendIDs(ctx context.Context, user models.Account) ([]uint, error) {
+	out, err := listCachedRelatives(ctx, friendCache, user, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listing account friends: %v", err)
+	}
+	return out, nil
+}
+
+func ListAccountBlockedUserIDs(ctx context.Context, user models.Account) ([]uint, error) {
+	out, err := listCachedRelatives(ctx, blocklistCache, user, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listing account blocked users: %v", err)
+	}
+	return out, nil
+}
+
+// InvalidateAccountRelationships drops the cached relationships of the accounts,
+// call it when the friends or the blocklist of them changed.
+func InvalidateAccountRelationships(id ...uint) {
+	for _, item := range id {
+		friendCache.Delete(item)
+		blocklistCache.Delete(item)
+		relativesFlight.Forget(relativesFlightKey(item, false))
+		relativesFlight.Forget(relativesFlightKey(item, true))
+	}
+}
+
+func GetRelationshipCacheStats() map[string]CacheStats {
+	return map[string]CacheStats{
+		"friends":   friendCache.Stats(),
+		"blocklist": blocklistCache.Stats(),
+	}
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"bytes"
+	"html"
+	"strings"
+
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/rs/zerolog/log"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	goldmarkHtml "github.com/yuin/goldmark/renderer/html"
+)
+
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+	goldmark.WithRendererOptions(goldmarkHtml.WithHardWraps()),
+)
+
+var (
+	htmlSanitizer   = bluemonday.UGCPolicy()
+	plaintextPolicy = bluemonday.StrictPolicy()
+)
+
+func RenderMarkdown(content string) (string, string, error) {
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(content), &buf); err != nil {
+		return "", "", err
+	}
+
+	rendered := htmlSanitizer.SanitizeBytes(buf.Bytes())
+	plaintext := html.UnescapeString(string(plaintextPolicy.SanitizeBytes(rendered)))
+	plaintext = strings.Join(strings.Fields(plaintext), " ")
+
+	return string(rendered), plaintext, nil
+}
+
+func RenderPostContent(item models.Post) (models.Post, error) {
+	content, ok := item.Body["content"].(string)
+	if !ok {
+		item.RenderedContent = ""
+		item.PlaintextContent = ""
+		return item, nil
+	}
+
+	var err error
+	item.RenderedContent, item.PlaintextContent, err = RenderMarkdown(content)
+	return item, err
+}
+
+// EnsurePostRendered renders the posts which are not backfilled yet in memory,
+// the reads never write the database, DoPostRenderBackfill stores the results instead.
+func EnsurePostRendered(item *models.Post) {
+	if len(item.RenderedContent) > 0 || item.Body["content"] == nil {
+		return
+	}
+
+	out, err := RenderPostContent(*item)
+	if err != nil {
+		log.Warn().Err(err).Uint("post", item.ID).Msg("Unable to render post content...")
+		return
+	}
+	item.RenderedContent = out.RenderedContent
+	item.PlaintextContent = out.PlaintextContent
+}
+
+// DoPostRenderBackfill renders the posts created before the rendering pipeline batch by batch,
+// those are the only ones left NULL, the posts rendered to nothing are stored as empty.
+// The articles without the table of contents are analyzed here as well.
+func DoPostRenderBackfill() {
+	var items []models.Post
+	if err := database.C.
+		Where("jsonb_typeof(body -> 'content') = 'string'").
+		Where("rendered_content IS NULL OR (type = ? AND NOT jsonb_exists(body, 'table_of_contents'))", models.PostTypeArticle).
+		Order("id ASC").
+		Limit(100).
+		Find(&items).Error; err != nil {
+		log.Error().Err(err).Msg("An error occurred when backfilling rendered posts...")
+		return
+	}
+
+	var count int
+	for _, item := range items {
+		out, err := RenderPostContent(item)
+		if err != nil {
+			// Store it as rendered to nothing, the reads render it in memory again anyway
+			log.Warn().Err(err).Uint("post", item.ID).Msg("Unable to render post content...")
+		}
+		columns := map[string]any{
+			"rendered_content":  out.RenderedContent,
+			"plaintext_content": out.PlaintextContent,
+		}
+		if item.Type == models.PostTypeArticle {
+			out = AnalyzeArticle(out)
+			columns["word_count"] = out.WordCount
+			columns["reading_time"] = out.ReadingTime
+			columns["body"] = out.Body
+		}
+		// The post edited meanwhile is rendered and analyzed by the edit already
+		if err := database.C.Model(&models.Post{}).
+			Where("id = ? AND updated_at = ?", item.ID, item.UpdatedAt).
+			UpdateColumns(columns).Error; err != nil {
+			log.Error().Err(err).Uint("post", item.ID).Msg("Unable to save rendered post content...")
+			continue
+		}
+		count++
+	}
+
+	if count > 0 {
+		log.Debug().Int("count", count).Msg("Backfilled rendered posts.")
+	}
+}
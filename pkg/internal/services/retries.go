@@ -0,0 +1,53 @@
+package services
+
+import (
+	"math"
+	"time"
+
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
+	"github.com/spf13/viper"
+)
+
+// retryPolicy reads the retry settings of a queue from the section named by the prefix,
+// the defaults are used when the settings are missing.
+type retryPolicy struct {
+	Prefix   string
+	Base     time.Duration
+	Limit    time.Duration
+	Lease    time.Duration
+	Attempts int
+}
+
+func (v retryPolicy) MaxAttempts() int {
+	if attempts := viper.GetInt(v.Prefix + ".max_attempts"); attempts > 0 {
+		return attempts
+	}
+	return v.Attempts
+}
+
+// Backoff doubles the delay on every attempt and stops growing at the limit.
+func (v retryPolicy) Backoff(attempts int) time.Duration {
+	base := viper.GetDuration(v.Prefix + ".retry_base")
+	if base <= 0 {
+		base = v.Base
+	}
+	limit := viper.GetDuration(v.Prefix + ".retry_max")
+	if limit <= 0 {
+		limit = v.Limit
+	}
+
+	backoff := time.Duration(float64(base) * math.Pow(2, float64(attempts-1)))
+	if backoff <= 0 || backoff > limit {
+		return limit
+	}
+	return backoff
+}
+
+// Claim takes a due pending item away from the other workers for the lease,
+// only the worker it returns true to should send the item.
+func (v retryPolicy) Claim(model any, id uint, pending string) bool {
+	tx := database.C.Model(model).
+		Where("id = ? AND status = ? AND next_attempt_at <= ?", id, pending, time.Now()).
+		Update("next_attempt_at", time.Now().Add(v.Lease))
+	return tx.Error == nil && tx.RowsAffected > 0
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	policy := retryPolicy{
+		Prefix:   "retry_test",
+		Base:     time.Second,
+		Limit:    10 * time.Second,
+		Attempts: 3,
+	}
+
+	if attempts := policy.MaxAttempts(); attempts != 3 {
+		t.Errorf("expected the default max attempts 3, got %d", attempts)
+	}
+	viper.Set("retry_test.max_attempts", 5)
+	t.Cleanup(func() { viper.Set("retry_test.max_attempts", nil) })
+	if attempts := policy.MaxAttempts(); attempts != 5 {
+		t.Errorf("expected the configured max attempts 5, got %d", attempts)
+	}
+
+	tests := []struct {
+		attempts int
+		backoff  time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second},
+		{100, 10 * time.Second},
+	}
+	for _, tc := range tests {
+		if backoff := policy.Backoff(tc.attempts); backoff != tc.backoff {
+			t.Errorf("expected backoff %v after %d attempts, got %v", tc.backoff, tc.attempts, backoff)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// The HTTP Signatures (draft-cavage-http-signatures) used by ActivityPub servers,
+// only rsa-sha256 is supported as it is the one every server speaks.
+
+const httpSignatureMaxSkew = 12 * time.Hour
+
+type httpSignature struct {
+	KeyID     string
+	Algorithm string
+	Headers   []string
+	Signature []byte
+}
+
+func parseHTTPSignature(header string) (httpSignature, error) {
+	var sign httpSignature
+	sign.Headers = []string{"date"}
+	for _, part := range strings.Split(header, ",") {
+		key, val, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		val = strings.Trim(val, `"`)
+		switch key {
+		case "keyId":
+			sign.KeyID = val
+		case "algorithm":
+			sign.Algorithm = val
+		case "headers":
+			sign.Headers = strings.Fields(strings.ToLower(val))
+		case "signature":
+			raw, err := base64.StdEncoding.DecodeString(val)
+			if err != nil {
+				return sign, fmt.Errorf("invalid signature encoding: %v", err)
+			}
+			sign.Signature = raw
+		}
+	}
+
+	if len(sign.KeyID) == 0 || len(sign.Signature) == 0 {
+		return sign, fmt.Errorf("signature missing keyId or signature")
+	}
+	if len(sign.Algorithm) > 0 && sign.Algorithm != "rsa-sha256" && sign.Algorithm != "hs2019" {
+		return sign, fmt.Errorf("unsupported signature algorithm %s", sign.Algorithm)
+	}
+	return sign, nil
+}
+
+func buildSigningString(method, target string, headers []string, get func(string) string) (string, error) {
+	lines := make([]string, 0, len(headers))
+	for _, name := range headers {
+		if name == "(request-target)" {
+			lines = append(lines, fmt.Sprintf("(request-target): %s %s", strings.ToLower(method), target))
+			continue
+		}
+		val := get(name)
+		if len(val) == 0 {
+			return "", fmt.Errorf("signed header %s is missing", name)
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", name, val))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func computeBodyDigest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "SHA-256=" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func GenerateActorKeyPair() (public string, private string, err error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return "", "", err
+	}
+	pub, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	public = string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub}))
+	private = string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+	return public, private, nil
+}
+
+func parsePublicKey(raw string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(raw))
+	if block == nil {
+		return nil, fmt.Errorf("invalid public key pem")
+	}
+	if key, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		if rsaKey, ok := key.(*rsa.PublicKey); ok {
+			return rsaKey, nil
+		}
+		return nil, fmt.Errorf("public key is not a rsa key")
+	}
+	return x509.ParsePKCS1PublicKey(block.Bytes)
+}
+
+func parsePrivateKey(raw string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(raw))
+	if block == nil {
+		return nil, fmt.Errorf("invalid private key pem")
+	}
+	return x509.ParsePKCS1PrivateKey(block.Bytes)
+}
+
+// SignHTTPRequest signs the request with the key, the body is needed to
+// compute the digest of the requests with body.
+func SignHTTPRequest(req *http.Request, keyID, privateKey string, body []byte) error {
+	key, err := parsePrivateKey(privateKey)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("Host", req.URL.Host)
+	headers := []string{"(request-target)", "host", "date"}
+	if body != nil {
+		req.Header.Set("Digest", computeBodyDigest(body))
+		headers = append(headers, "digest")
+	}
+
+	target := req.URL.RequestURI()
+	str, err := buildSigningString(req.Method, target, headers, func(name string) string {
+		if name == "host" {
+			return req.URL.Host
+		}
+		return req.Header.Get(name)
+	})
+	if err != nil {
+		return err
+	}
+
+	hashed := sha256.Sum256([]byte(str))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Signature", fmt.Sprintf(
+		`keyId="%s",algorithm="rsa-sha256",headers="%s",signature="%s"`,
+		keyID,
+		strings.Join(headers, " "),
+		base64.StdEncoding.EncodeToString(signature),
+	))
+	return nil
+}
+
+// verifyHTTPSignature checks the signature of an incoming request against the public key,
+// the date and the digest are required to be signed to resist replaying and tampering.
+func verifyHTTPSignature(sign httpSignature, method, target string, get func(string) string, body []byte, publicKey string) error {
+	if date, err := http.ParseTime(get("date")); err != nil {
+		return fmt.Errorf("invalid date header: %v", err)
+	} else if skew := time.Since(date); skew > httpSignatureMaxSkew || skew < -httpSignatureMaxSkew {
+		return fmt.Errorf("request date is too far from now")
+	}
+
+	signed := func(name string) bool {
+		for _, item := range sign.Headers {
+			if item == name {
+				return true
+			}
+		}
+		return false
+	}
+	if !signed("(request-target)") || !signed("date") {
+		return fmt.Errorf("request target and date must be signed")
+	}
+	if len(body) > 0 {
+		if !signed("digest") {
+			return fmt.Errorf("digest must be signed")
+		} else if get("digest") != computeBodyDigest(body) {
+			return fmt.Errorf("digest mismatch")
+		}
+	}
+
+	key, err := parsePublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+	str, err := buildSigningString(method, target, sign.Headers, get)
+	if err != nil {
+		return err
+	}
+
+	hashed := sha256.Sum256([]byte(str))
+	return rsa.VerifyPKCS1v15(key, crypto.SHA256, hashed[:], sign.Signature)
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// requestHeaderGetter reads the headers like the inbox handler does, the host is not a header in net/http.
+func requestHeaderGetter(r *http.Request) func(string) string {
+	return func(name string) string {
+		if name == "host" {
+			return r.Host
+		}
+		return r.Header.Get(name)
+	}
+}
+
+func TestHTTPSignatureRoundTrip(t *testing.T) {
+	public, private, err := GenerateActorKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPublic, _, err := GenerateActorKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		publicKey string
+		// tamper changes the request after it was signed
+		tamper func(req *http.Request, body []byte) []byte
+		valid  bool
+	}{
+		{name: "valid", publicKey: public, valid: true},
+		{name: "wrong key", publicKey: otherPublic, valid: false},
+		{
+			name:      "tampered body",
+			publicKey: public,
+			tamper: func(req *http.Request, body []byte) []byte {
+				return append(body, ' ')
+			},
+			valid: false,
+		},
+		{
+			name:      "tampered path",
+			publicKey: public,
+			tamper: func(req *http.Request, body []byte) []byte {
+				req.URL.Path = "/inbox/other"
+				return body
+			},
+			valid: false,
+		},
+		{
+			name:      "stale date",
+			publicKey: public,
+			tamper: func(req *http.Request, body []byte) []byte {
+				req.Header.Set("Date", "Mon, 02 Jan 2006 15:04:05 GMT")
+				return body
+			},
+			valid: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			results := make(chan error, 1)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				sign, err := parseHTTPSignature(r.Header.Get("Signature"))
+				if err == nil {
+					err = verifyHTTPSignature(sign, r.Method, r.URL.RequestURI(), requestHeaderGetter(r), body, tc.publicKey)
+				}
+				results <- err
+				w.WriteHeader(http.StatusAccepted)
+			}))
+			defer server.Close()
+
+			body := []byte(`{"type":"Follow"}`)
+			req, err := http.NewRequest(http.MethodPost, server.URL+"/inbox", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := SignHTTPRequest(req, "https://remote.test/users/bob#main-key", private, body); err != nil {
+				t.Fatal(err)
+			}
+			if tc.tamper != nil {
+				body = tc.tamper(req, body)
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+			req.ContentLength = int64(len(body))
+
+			resp, err := server.Client().Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = resp.Body.Close()
+
+			verifyErr := <-results
+			if tc.valid && verifyErr != nil {
+				t.Errorf("expected signature to be valid, got %v", verifyErr)
+			} else if !tc.valid && verifyErr == nil {
+				t.Errorf("expected signature to be rejected")
+			}
+		})
+	}
+}
@@ -0,0 +1,301 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	"github.com/rs/zerolog/log"
+	"github.com/samber/lo"
+	"github.com/spf13/viper"
+)
+
+const (
+	PostEventCreated = "post.created"
+	PostEventEdited  = "post.edited"
+	PostEventDeleted = "post.deleted"
+	PostEventReacted = "post.reacted"
+	PostEventReplied = "post.replied"
+)
+
+// PostEvent is published when a post changes, the fields without json names
+// are used to route the event to the subscribers and never leave the server.
+type PostEvent struct {
+	Type       string           `json:"type"`
+	PostID     uint             `json:"post_id"`
+	Reactions  map[string]int64 `json:"reactions,omitempty"`
+	ReplyCount *int64           `json:"reply_count,omitempty"`
+	CreatedAt  time.Time        `json:"created_at"`
+
+	AuthorID   uint     `json:"-"`
+	RealmID    *uint    `json:"-"`
+	ReplyID    *uint    `json:"-"`
+	Tags       []string `json:"-"`
+	Categories []string `json:"-"`
+
+	// Snapshot is the post loaded once when publishing, shared by every connection
+	Snapshot *models.Post `json:"-"`
+	// AudienceListIDs are the audience lists the post targets, see Snapshot
+	AudienceListIDs []uint `json:"-"`
+}
+
+// EventBroker fans out the post events to the subscribers, the in-process broker
+// only reaches the connections of this instance, replace it to go across instances.
+type EventBroker interface {
+	Publish(event PostEvent)
+	Subscribe() (<-chan PostEvent, func())
+}
+
+type MemoryEventBroker struct {
+	buffer      int
+	subscribers map[chan PostEvent]struct{}
+	lock        sync.RWMutex
+}
+
+func NewMemoryEventBroker(buffer int) *MemoryEventBroker {
+	return &MemoryEventBroker{
+		buffer:      buffer,
+		subscribers: make(map[chan PostEvent]struct{}),
+	}
+}
+
+func (v *MemoryEventBroker) Publish(event PostEvent) {
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+
+	for ch := range v.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// The subscriber is too slow, drop the event instead of blocking the publisher
+			log.Warn().Str("type", event.Type).Uint("post", event.PostID).Msg("Dropped post event for a slow subscriber...")
+		}
+	}
+}
+
+func (v *MemoryEventBroker) Subscribe() (<-chan PostEvent, func()) {
+	ch := make(chan PostEvent, v.buffer)
+
+	v.lock.Lock()
+	v.subscribers[ch] = struct{}{}
+	v.lock.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			v.lock.Lock()
+			delete(v.subscribers, ch)
+			v.lock.Unlock()
+			close(ch)
+		})
+	}
+}
+
+var eventBroker EventBroker = NewMemoryEventBroker(64)
+
+func SetEventBroker(broker EventBroker) {
+	eventBroker = broker
+}
+
+func SubscribePostEvents() (<-chan PostEvent, func()) {
+	return eventBroker.Subscribe()
+}
+
+func newPostEvent(kind string, post models.Post) PostEvent {
+	event := PostEvent{
+		Type:      kind,
+		PostID:    post.ID,
+		CreatedAt: time.Now(),
+		AuthorID:  post.AuthorID,
+		RealmID:   post.RealmID,
+		ReplyID:   post.ReplyID,
+	}
+
+	if post.Tags != nil || post.Categories != nil {
+		event.Tags = lo.Map(post.Tags, func(item models.Tag, index int) string { return item.Alias })
+		event.Categories = lo.Map(post.Categories, func(item models.Category, index int) string { return item.Alias })
+	} else {
+		prefix := viper.GetString("database.prefix")
+		database.C.Model(&models.Tag{}).
+			Joins("JOIN "+prefix+"post_tags ON "+prefix+"post_tags.tag_id = "+prefix+"tags.id").
+			Where(prefix+"post_tags.post_id = ?", post.ID).
+			Pluck(prefix+"tags.alias", &event.Tags)
+		database.C.Model(&models.Category{}).
+			Joins("JOIN "+prefix+"post_categories ON "+prefix+"post_categories.category_id = "+prefix+"categories.id").
+			Where(prefix+"post_categories.post_id = ?", post.ID).
+			Pluck(prefix+"categories.alias", &event.Categories)
+	}
+
+	return event
+}
+
+// PublishPostEvent publishes the change of the post to the streaming connections.
+func PublishPostEvent(kind string, post models.Post) {
+	event := newPostEvent(kind, post)
+
+	switch kind {
+	case PostEventReacted:
+		event.Reactions, _ = ListPostReactions(database.C.Where("post_id = ?", post.ID))
+	case PostEventReplied:
+		// The event is shared by every connection, so only the public replies are counted
+		event.ReplyCount = lo.ToPtr(CountPostReply(post.ID, nil))
+	}
+
+	// Load the post once here instead of once per connection, the deleted posts are loaded as they were
+	tx := database.C
+	if kind == PostEventDeleted {
+		tx = tx.Unscoped()
+	}
+	if snapshot, err := GetPost(tx, post.ID, true); err != nil {
+		log.Warn().Err(err).Uint("post", post.ID).Msg("Unable to load post for event, it won't be streamed...")
+	} else {
+		event.Snapshot = &snapshot
+	}
+	database.C.Table(viper.GetString("database.prefix")+"post_audience_lists").
+		Where("post_id = ?", post.ID).
+		Pluck("audience_list_id", &event.AudienceListIDs)
+
+	eventBroker.Publish(event)
+}
+
+// PostEventFilter describes the feed and the threads a streaming connection subscribed,
+// the feed has every top-level post when no feed filter is set.
+type PostEventFilter struct {
+	IsFeed    bool
+	AuthorID  *uint
+	RealmID   *uint
+	Tag       *string
+	Category  *string
+	WithReply bool
+	Threads   []uint
+}
+
+func (v PostEventFilter) Match(event PostEvent) bool {
+	// The changes of the subscribed threads and the posts replying them
+	if lo.Contains(v.Threads, event.PostID) || (event.ReplyID != nil && lo.Contains(v.Threads, *event.ReplyID)) {
+		return true
+	}
+	if !v.IsFeed {
+		return false
+	}
+
+	if !v.WithReply && event.ReplyID != nil {
+		return false
+	}
+	if v.AuthorID != nil && event.AuthorID != *v.AuthorID {
+		return false
+	}
+	if v.RealmID != nil && (event.RealmID == nil || *event.RealmID != *v.RealmID) {
+		return false
+	}
+	if v.Tag != nil && !lo.Contains(event.Tags, *v.Tag) {
+		return false
+	}
+	if v.Category != nil && !lo.Contains(event.Categories, *v.Category) {
+		return false
+	}
+
+	return true
+}
+
+// streamViewerTTL is how long a connection keeps the relationships of the user
+const streamViewerTTL = time.Minute
+
+// PostStreamViewer checks the posts in the events for a streaming connection in memory,
+// with the relationships of the user loaded once a while, so the events cost no queries.
+// The rules must be kept the same as FilterPostReadable and FilterPostWithUserContext.
+type PostStreamViewer struct {
+	User *models.Account
+
+	friends      []uint
+	blocklist    []uint
+	muted        []uint
+	audiences    []uint
+	isFailClosed bool
+	refreshedAt  time.Time
+}
+
+func NewPostStreamViewer(user *models.Account) *PostStreamViewer {
+	return &PostStreamViewer{User: user}
+}
+
+func (v *PostStreamViewer) refresh() {
+	if v.User == nil || time.Since(v.refreshedAt) < streamViewerTTL {
+		return
+	}
+	v.refreshedAt = time.Now()
+
+	ctx := context.Background()
+	var err error
+	if v.friends, err = ListAccountFriendIDs(ctx, *v.User); err != nil {
+		log.Warn().Err(err).Uint("user", v.User.ID).Msg("Unable to get friends, streaming posts without them...")
+	}
+	v.blocklist, err = ListAccountBlockedUserIDs(ctx, *v.User)
+	// Refuse to stream posts rather than leaking the blocked users' posts
+	v.isFailClosed = err != nil && viper.GetBool("relationship.blocklist_fail_closed")
+	if err != nil {
+		log.Warn().Err(err).Uint("user", v.User.ID).Msg("Unable to get blocklist, streaming posts without it...")
+	}
+
+	v.muted, v.audiences = nil, nil
+	mutedAccountsOf(v.User.ID).Pluck("muted_id", &v.muted)
+	database.C.Model(&models.AudienceListMember{}).
+		Where("account_id = ?", v.User.ID).
+		Pluck("list_id", &v.audiences)
+}
+
+// CanRead checks the user can read the post, the audience lists are the ones the post targets.
+func (v *PostStreamViewer) CanRead(post models.Post, audiences []uint) bool {
+	now := time.Now()
+	if post.IsDraft {
+		return false
+	}
+	if (post.PublishedAt != nil && post.PublishedAt.After(now)) || (post.PublishedUntil != nil && !post.PublishedUntil.After(now)) {
+		return false
+	}
+
+	if v.User == nil {
+		return post.Visibility == models.PostVisibilityAll
+	} else if post.AuthorID == v.User.ID {
+		return true
+	}
+
+	v.refresh()
+	if v.isFailClosed || lo.Contains(v.blocklist, post.AuthorID) || lo.Contains(v.muted, post.AuthorID) {
+		return false
+	}
+
+	switch post.Visibility {
+	case models.PostVisibilityAll:
+		return true
+	case models.PostVisibilityFriends:
+		return lo.Contains(v.friends, post.AuthorID)
+	case models.PostVisibilityFiltered:
+		return !lo.Contains(post.InvisibleUsers, v.User.ID)
+	case models.PostVisibilitySelected:
+		return lo.Contains(post.VisibleUsers, v.User.ID) || len(lo.Intersect(audiences, v.audiences)) > 0
+	}
+	return false
+}
+
+// GetStreamablePost returns the post of the event when the user can read it,
+// the replied and reposted posts are removed when the user cannot read them.
+func (v *PostStreamViewer) GetStreamablePost(event PostEvent) (models.Post, bool) {
+	if event.Snapshot == nil || !v.CanRead(*event.Snapshot, event.AudienceListIDs) {
+		return models.Post{}, false
+	}
+
+	// Copy the snapshot, it is shared with the other connections
+	post := *event.Snapshot
+	// The audience lists of the relatives are not loaded, so the selected ones are dropped
+	if post.ReplyTo != nil && !v.CanRead(*post.ReplyTo, nil) {
+		post.ReplyTo = nil
+	}
+	if post.RepostTo != nil && !v.CanRead(*post.RepostTo, nil) {
+		post.RepostTo = nil
+	}
+
+	return post, true
+}
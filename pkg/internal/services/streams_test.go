@@ -0,0 +1,197 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	"github.com/samber/lo"
+	"gorm.io/datatypes"
+)
+
+func TestPostStreamViewerCanRead(t *testing.T) {
+	const author, viewer, friend = 1, 2, 3
+
+	// The relationships are set as refreshed, so they are not loaded from the database
+	newViewer := func(prepare func(v *PostStreamViewer)) *PostStreamViewer {
+		v := NewPostStreamViewer(&models.Account{})
+		v.User.ID = viewer
+		v.refreshedAt = time.Now()
+		if prepare != nil {
+			prepare(v)
+		}
+		return v
+	}
+
+	tests := []struct {
+		name      string
+		post      models.Post
+		audiences []uint
+		anonymous bool
+		prepare   func(v *PostStreamViewer)
+		want      bool
+	}{
+		{name: "public to anonymous", post: models.Post{Visibility: models.PostVisibilityAll}, anonymous: true, want: true},
+		{name: "friends to anonymous", post: models.Post{Visibility: models.PostVisibilityFriends}, anonymous: true, want: false},
+		{name: "draft", post: models.Post{Visibility: models.PostVisibilityAll, IsDraft: true}, want: false},
+		{
+			name: "scheduled",
+			post: models.Post{Visibility: models.PostVisibilityAll, PublishedAt: lo.ToPtr(time.Now().Add(time.Hour))},
+			want: false,
+		},
+		{
+			name: "expired",
+			post: models.Post{Visibility: models.PostVisibilityAll, PublishedUntil: lo.ToPtr(time.Now().Add(-time.Hour))},
+			want: false,
+		},
+		{name: "own private", post: models.Post{Visibility: models.PostVisibilityNone, AuthorID: viewer}, want: true},
+		{name: "private", post: models.Post{Visibility: models.PostVisibilityNone}, want: false},
+		{
+			name:    "friends to friend",
+			post:    models.Post{Visibility: models.PostVisibilityFriends, AuthorID: friend},
+			prepare: func(v *PostStreamViewer) { v.friends = []uint{friend} },
+			want:    true,
+		},
+		{name: "friends to stranger", post: models.Post{Visibility: models.PostVisibilityFriends, AuthorID: author}, want: false},
+		{name: "filtered", post: models.Post{Visibility: models.PostVisibilityFiltered}, want: true},
+		{
+			name: "filtered to invisible user",
+			post: models.Post{Visibility: models.PostVisibilityFiltered, InvisibleUsers: datatypes.NewJSONSlice([]uint{viewer})},
+			want: false,
+		},
+		{
+			name: "selected to visible user",
+			post: models.Post{Visibility: models.PostVisibilitySelected, VisibleUsers: datatypes.NewJSONSlice([]uint{viewer})},
+			want: true,
+		},
+		{
+			name:      "selected to audience member",
+			post:      models.Post{Visibility: models.PostVisibilitySelected},
+			audiences: []uint{7},
+			prepare:   func(v *PostStreamViewer) { v.audiences = []uint{7} },
+			want:      true,
+		},
+		{name: "selected to stranger", post: models.Post{Visibility: models.PostVisibilitySelected}, audiences: []uint{7}, want: false},
+		{
+			name:    "blocked author",
+			post:    models.Post{Visibility: models.PostVisibilityAll, AuthorID: author},
+			prepare: func(v *PostStreamViewer) { v.blocklist = []uint{author} },
+			want:    false,
+		},
+		{
+			name:    "muted author",
+			post:    models.Post{Visibility: models.PostVisibilityAll, AuthorID: author},
+			prepare: func(v *PostStreamViewer) { v.muted = []uint{author} },
+			want:    false,
+		},
+		{
+			name:    "blocklist unavailable",
+			post:    models.Post{Visibility: models.PostVisibilityAll, AuthorID: author},
+			prepare: func(v *PostStreamViewer) { v.isFailClosed = true },
+			want:    false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			v := NewPostStreamViewer(nil)
+			if !tc.anonymous {
+				v = newViewer(tc.prepare)
+			}
+			if got := v.CanRead(tc.post, tc.audiences); got != tc.want {
+				t.Errorf("CanRead = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPostStreamViewerRedactsRelatives(t *testing.T) {
+	v := NewPostStreamViewer(nil)
+	snapshot := &models.Post{
+		Visibility: models.PostVisibilityAll,
+		ReplyTo:    &models.Post{Visibility: models.PostVisibilityFriends},
+		RepostTo:   &models.Post{Visibility: models.PostVisibilityAll},
+	}
+
+	post, ok := v.GetStreamablePost(PostEvent{Snapshot: snapshot})
+	if !ok {
+		t.Fatal("public post is not streamable")
+	}
+	if post.ReplyTo != nil {
+		t.Errorf("unreadable replied post is kept")
+	}
+	if post.RepostTo == nil {
+		t.Errorf("readable reposted post is removed")
+	}
+	if snapshot.ReplyTo == nil {
+		t.Errorf("shared snapshot is modified")
+	}
+}
+
+func TestPostEventFilterMatch(t *testing.T) {
+	const author, realm = 1, 2
+
+	tests := []struct {
+		name   string
+		filter PostEventFilter
+		event  PostEvent
+		want   bool
+	}{
+		{
+			name:   "replied in the feed",
+			filter: PostEventFilter{IsFeed: true},
+			event:  PostEvent{Type: PostEventReplied, PostID: 10, AuthorID: author},
+			want:   true,
+		},
+		{
+			name:   "replied by the subscribed author",
+			filter: PostEventFilter{IsFeed: true, AuthorID: lo.ToPtr(uint(author))},
+			event:  PostEvent{Type: PostEventReplied, PostID: 10, AuthorID: author},
+			want:   true,
+		},
+		{
+			name:   "replied by another author",
+			filter: PostEventFilter{IsFeed: true, AuthorID: lo.ToPtr(uint(author))},
+			event:  PostEvent{Type: PostEventReplied, PostID: 10, AuthorID: author + 1},
+			want:   false,
+		},
+		{
+			name:   "replied outside the subscribed realm",
+			filter: PostEventFilter{IsFeed: true, RealmID: lo.ToPtr(uint(realm))},
+			event:  PostEvent{Type: PostEventReplied, PostID: 10},
+			want:   false,
+		},
+		{
+			name:   "replied without the subscribed tag",
+			filter: PostEventFilter{IsFeed: true, Tag: lo.ToPtr("go")},
+			event:  PostEvent{Type: PostEventReplied, PostID: 10, Tags: []string{"rust"}},
+			want:   false,
+		},
+		{
+			name:   "replied in the subscribed category",
+			filter: PostEventFilter{IsFeed: true, Category: lo.ToPtr("news")},
+			event:  PostEvent{Type: PostEventReplied, PostID: 10, Categories: []string{"news"}},
+			want:   true,
+		},
+		{
+			name:   "replied in the subscribed thread",
+			filter: PostEventFilter{Threads: []uint{10}},
+			event:  PostEvent{Type: PostEventReplied, PostID: 10},
+			want:   true,
+		},
+		{
+			name:   "replied outside the threads",
+			filter: PostEventFilter{Threads: []uint{11}},
+			event:  PostEvent{Type: PostEventReplied, PostID: 10},
+			want:   false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.filter.Match(tc.event); got != tc.want {
+				t.Errorf("match = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"git.solsynth.dev/hydrogen/dealer/pkg/proto"
 	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
 	"git.solsynth.dev/hydrogen/interactive/pkg/internal/gap"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/metrics"
 	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
 	"gorm.io/gorm"
 )
@@ -180,7 +181,11 @@ func UnsubscribeFromRealm(user models.Account, target models.Realm) error {
 
 func NotifyUserSubscription(poster models.Account, content string, title *string) error {
 	var subscriptions []models.Subscription
-	if err := database.C.Where("account_id = ?", poster.ID).Preload("Follower").Find(&subscriptions).Error; err != nil {
+	if err := database.C.
+		Where("account_id = ?", poster.ID).
+		Where("follower_id NOT IN (?)", mutingAccountsOf(poster.ID)).
+		Preload("Follower").
+		Find(&subscriptions).Error; err != nil {
 		return fmt.Errorf("unable to get subscriptions: %v", err)
 	}
 
@@ -205,6 +210,8 @@ func NotifyUserSubscription(poster models.Account, content string, title *string
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 
+	metrics.ObserveNotificationFanout("interactive.subscription.account", len(userIDs))
+	ctx, done := observeGrpc(ctx, "Notifier", "NotifyUserBatch")
 	_, err = proto.NewNotifierClient(pc).NotifyUserBatch(ctx, &proto.NotifyUserBatchRequest{
 		UserId: userIDs,
 		Notify: &proto.NotifyRequest{
@@ -216,13 +223,18 @@ func NotifyUserSubscription(poster models.Account, content string, title *string
 			IsForcePush: true,
 		},
 	})
+	done(err)
 
 	return err
 }
 
 func NotifyTagSubscription(poster models.Tag, og models.Account, content string, title *string) error {
 	var subscriptions []models.Subscription
-	if err := database.C.Where("tag_id = ?", poster.ID).Preload("Follower").Find(&subscriptions).Error; err != nil {
+	if err := database.C.
+		Where("tag_id = ?", poster.ID).
+		Where("follower_id NOT IN (?)", mutingAccountsOf(og.ID)).
+		Preload("Follower").
+		Find(&subscriptions).Error; err != nil {
 		return fmt.Errorf("unable to get subscriptions: %v", err)
 	}
 
@@ -247,6 +259,8 @@ func NotifyTagSubscription(poster models.Tag, og models.Account, content string,
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 
+	metrics.ObserveNotificationFanout("interactive.subscription.tag", len(userIDs))
+	ctx, done := observeGrpc(ctx, "Notifier", "NotifyUserBatch")
 	_, err = proto.NewNotifierClient(pc).NotifyUserBatch(ctx, &proto.NotifyUserBatchRequest{
 		UserId: userIDs,
 		Notify: &proto.NotifyRequest{
@@ -258,13 +272,18 @@ func NotifyTagSubscription(poster models.Tag, og models.Account, content string,
 			IsForcePush: true,
 		},
 	})
+	done(err)
 
 	return err
 }
 
 func NotifyCategorySubscription(poster models.Category, og models.Account, content string, title *string) error {
 	var subscriptions []models.Subscription
-	if err := database.C.Where("category_id = ?", poster.ID).Preload("Follower").Find(&subscriptions).Error; err != nil {
+	if err := database.C.
+		Where("category_id = ?", poster.ID).
+		Where("follower_id NOT IN (?)", mutingAccountsOf(og.ID)).
+		Preload("Follower").
+		Find(&subscriptions).Error; err != nil {
 		return fmt.Errorf("unable to get subscriptions: %v", err)
 	}
 
@@ -289,6 +308,8 @@ func NotifyCategorySubscription(poster models.Category, og models.Account, conte
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 
+	metrics.ObserveNotificationFanout("interactive.subscription.category", len(userIDs))
+	ctx, done := observeGrpc(ctx, "Notifier", "NotifyUserBatch")
 	_, err = proto.NewNotifierClient(pc).NotifyUserBatch(ctx, &proto.NotifyUserBatchRequest{
 		UserId: userIDs,
 		Notify: &proto.NotifyRequest{
@@ -300,13 +321,18 @@ func NotifyCategorySubscription(poster models.Category, og models.Account, conte
 			IsForcePush: true,
 		},
 	})
+	done(err)
 
 	return err
 }
 
 func NotifyRealmSubscription(poster models.Realm, og models.Account, content string, title *string) error {
 	var subscriptions []models.Subscription
-	if err := database.C.Where("realm_id = ?", poster.ID).Preload("Follower").Find(&subscriptions).Error; err != nil {
+	if err := database.C.
+		Where("realm_id = ?", poster.ID).
+		Where("follower_id NOT IN (?)", mutingAccountsOf(og.ID)).
+		Preload("Follower").
+		Find(&subscriptions).Error; err != nil {
 		return fmt.Errorf("unable to get subscriptions: %v", err)
 	}
 
@@ -331,6 +357,8 @@ func NotifyRealmSubscription(poster models.Realm, og models.Account, content str
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 
+	metrics.ObserveNotificationFanout("interactive.subscription.realm", len(userIDs))
+	ctx, done := observeGrpc(ctx, "Notifier", "NotifyUserBatch")
 	_, err = proto.NewNotifierClient(pc).NotifyUserBatch(ctx, &proto.NotifyUserBatchRequest{
 		UserId: userIDs,
 		Notify: &proto.NotifyRequest{
@@ -342,6 +370,7 @@ func NotifyRealmSubscription(poster models.Realm, og models.Account, content str
 			IsForcePush: true,
 		},
 	})
+	done(err)
 
 	return err
 }
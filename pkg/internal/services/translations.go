@@ -0,0 +1,123 @@
+package services
+
+import (
+	"fmt"
+	"maps"
+
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	"github.com/samber/lo"
+	"gorm.io/datatypes"
+)
+
+func ListPostTranslations(post models.Post) ([]models.PostTranslation, error) {
+	var translations []models.PostTranslation
+	if err := database.C.
+		Where("post_id = ?", post.ID).
+		Preload("Translator").
+		Find(&translations).Error; err != nil {
+		return translations, err
+	}
+
+	return translations, nil
+}
+
+func SetPostTranslation(user models.Account, post models.Post, lang string, body datatypes.JSONMap) (models.PostTranslation, error) {
+	translation := models.PostTranslation{
+		Language:     lang,
+		PostID:       post.ID,
+		TranslatorID: user.ID,
+	}
+	if lang == post.Language {
+		return translation, fmt.Errorf("post was written in %s already", lang)
+	}
+
+	// Render the translation like a post to fill the generated fields
+	localized, err := RenderPostContent(models.Post{Type: post.Type, Body: body})
+	if err != nil {
+		return translation, fmt.Errorf("unable to render translation content: %v", err)
+	}
+	if localized.Type == models.PostTypeArticle {
+		localized = AnalyzeArticle(localized)
+	}
+	translation.Body = localized.Body
+
+	if err := database.C.Unscoped().
+		Where("post_id = ? AND language = ?", post.ID, lang).
+		Delete(&models.PostTranslation{}).Error; err != nil {
+		return translation, err
+	}
+
+	err = database.C.Save(&translation).Error
+	return translation, err
+}
+
+func DeletePostTranslation(post models.Post, lang string) error {
+	tx := database.C.Unscoped().
+		Where("post_id = ? AND language = ?", post.ID, lang).
+		Delete(&models.PostTranslation{})
+	if tx.Error != nil {
+		return tx.Error
+	} else if tx.RowsAffected == 0 {
+		return fmt.Errorf("translation does not exist")
+	}
+	return nil
+}
+
+// LocalizePosts replaces the body of the posts with the translation in the
+// most preferred language, languages are ordered by preference.
+func LocalizePosts(items []*models.Post, languages []string) error {
+	items = lo.Filter(items, func(item *models.Post, index int) bool {
+		return item != nil
+	})
+	if len(items) == 0 || len(languages) == 0 {
+		return nil
+	}
+
+	idx := lo.Map(items, func(item *models.Post, index int) uint {
+		return item.ID
+	})
+
+	var translations []models.PostTranslation
+	if err := database.C.
+		Where("post_id IN ? AND language IN ?", idx, languages).
+		Find(&translations).Error; err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		for _, lang := range languages {
+			if lang == item.Language {
+				break
+			}
+			translation, ok := lo.Find(translations, func(v models.PostTranslation) bool {
+				return v.PostID == item.ID && v.Language == lang
+			})
+			if ok {
+				localizePost(item, translation)
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+func localizePost(item *models.Post, translation models.PostTranslation) {
+	body := maps.Clone(item.Body)
+	for k, v := range translation.Body {
+		if v != nil {
+			body[k] = v
+		}
+	}
+
+	original := item.Language
+	item.Body = body
+	item.Language = translation.Language
+	item.TranslatedFrom = &original
+
+	if rendered, err := RenderPostContent(*item); err == nil {
+		item.RenderedContent = rendered.RenderedContent
+		item.PlaintextContent = rendered.PlaintextContent
+	}
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	"github.com/rs/zerolog/log"
+	"github.com/samber/lo"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// postViewBuffer counts the views in memory and flushes them into the database in batches,
+// a viewer is only counted once per post in the dedup window.
+type postViewBuffer struct {
+	seen    map[string]time.Time
+	pending map[uint]int64
+	lock    sync.Mutex
+}
+
+var postViews = &postViewBuffer{
+	seen:    make(map[string]time.Time),
+	pending: make(map[uint]int64),
+}
+
+func getPostViewWindow() time.Duration {
+	if window := viper.GetDuration("analytics.view_window"); window > 0 {
+		return window
+	}
+	return 30 * time.Minute
+}
+
+// RecordPostView counts a view of the post, the viewer is the account id or the ip of an anonymous visitor.
+func RecordPostView(post models.Post, viewer string) {
+	key := fmt.Sprintf("%d:%s", post.ID, viewer)
+
+	postViews.lock.Lock()
+	defer postViews.lock.Unlock()
+
+	if seenAt, ok := postViews.seen[key]; ok && time.Since(seenAt) < getPostViewWindow() {
+		return
+	}
+	postViews.seen[key] = time.Now()
+	postViews.pending[post.ID]++
+}
+
+// FlushPostViews writes the buffered views into the post totals and the daily views.
+func FlushPostViews() {
+	postViews.lock.Lock()
+	pending := postViews.pending
+	postViews.pending = make(map[uint]int64)
+	for key, seenAt := range postViews.seen {
+		if time.Since(seenAt) >= getPostViewWindow() {
+			delete(postViews.seen, key)
+		}
+	}
+	postViews.lock.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+
+	// The posts deleted after they were viewed would fail the batch on every flush, drop them
+	var existing []uint
+	if err := database.C.Model(&models.Post{}).
+		Where("id IN ?", lo.Keys(pending)).
+		Pluck("id", &existing).Error; err != nil {
+		restorePostViews(pending)
+		log.Error().Err(err).Int("posts", len(pending)).Msg("An error occurred when flushing post views...")
+		return
+	}
+	for id := range pending {
+		if !lo.Contains(existing, id) {
+			delete(pending, id)
+		}
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	date := time.Now().UTC().Truncate(24 * time.Hour)
+	table := viper.GetString("database.prefix") + "post_daily_views"
+	err := database.C.Transaction(func(tx *gorm.DB) error {
+		for id, count := range pending {
+			if err := tx.Model(&models.Post{}).
+				Where("id = ?", id).
+				UpdateColumn("total_views", gorm.Expr("COALESCE(total_views, 0) + ?", count)).Error; err != nil {
+				return err
+			}
+
+			view := models.PostDailyView{Date: date, Count: count, PostID: id}
+			if err := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "date"}, {Name: "post_id"}},
+				DoUpdates: clause.Assignments(map[string]any{
+					"count":      gorm.Expr(fmt.Sprintf("%s.count + EXCLUDED.count", table)),
+					"updated_at": time.Now(),
+				}),
+			}).Create(&view).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		restorePostViews(pending)
+		log.Error().Err(err).Int("posts", len(pending)).Msg("An error occurred when flushing post views...")
+		return
+	}
+
+	log.Debug().Int("posts", len(pending)).Msg("Flushed post views.")
+}
+
+// restorePostViews puts the views back so the next flush can try again.
+func restorePostViews(pending map[uint]int64) {
+	postViews.lock.Lock()
+	defer postViews.lock.Unlock()
+
+	for id, count := range pending {
+		postViews.pending[id] += count
+	}
+}
@@ -0,0 +1,379 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/models"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/rs/zerolog/log"
+	"github.com/samber/lo"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+const webhookResponseLimit = 4096
+
+var webhookRetry = retryPolicy{
+	Prefix:   "webhook",
+	Base:     30 * time.Second,
+	Limit:    6 * time.Hour,
+	Lease:    time.Minute,
+	Attempts: 8,
+}
+
+// webhookSenders limits the deliveries sending at the same time, a slow endpoint
+// shouldn't hold the deliveries to the other endpoints back.
+var webhookSenders = make(chan struct{}, 16)
+
+// getWebhookClient builds the client once, it is shared by the concurrent deliveries.
+var getWebhookClient = sync.OnceValue(func() *http.Client {
+	if viper.GetBool("webhook.allow_private_network") {
+		return &http.Client{Timeout: 10 * time.Second}
+	}
+	return newPublicNetworkClient(10 * time.Second)
+})
+
+type WebhookPayload struct {
+	Event     string    `json:"event"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+func ListWebhooks(user models.Account) ([]models.Webhook, error) {
+	var hooks []models.Webhook
+	if err := database.C.
+		Where("account_id = ?", user.ID).
+		Preload("Realm").
+		Order("created_at ASC").
+		Find(&hooks).Error; err != nil {
+		return hooks, err
+	}
+
+	return hooks, nil
+}
+
+func GetWebhookWithUser(id uint, user models.Account) (models.Webhook, error) {
+	var hook models.Webhook
+	if err := database.C.
+		Where("id = ? AND account_id = ?", id, user.ID).
+		Preload("Realm").
+		First(&hook).Error; err != nil {
+		return hook, err
+	}
+	return hook, nil
+}
+
+func validateWebhook(hook models.Webhook) error {
+	if u, err := url.Parse(hook.URL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || len(u.Host) == 0 {
+		return fmt.Errorf("invalid webhook url %s", hook.URL)
+	}
+	if len(hook.Events) == 0 {
+		return fmt.Errorf("webhook must subscribe at least one event")
+	}
+	for _, event := range hook.Events {
+		if !lo.Contains(models.WebhookEvents, event) {
+			return fmt.Errorf("unknown webhook event %s", event)
+		}
+	}
+
+	return nil
+}
+
+// EnsureRealmWebhookPerm checks the user is allowed to manage the webhooks of the realm.
+func EnsureRealmWebhookPerm(ctx context.Context, realmId uint, user models.Account) error {
+	return EnsureRealmPowerLevel(ctx, realmId, user, viper.GetInt32("webhook.realm_power_level"), "manage its webhooks")
+}
+
+func newWebhookSecret() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw), nil
+}
+
+func NewWebhook(user models.Account, hook models.Webhook) (models.Webhook, error) {
+	if err := validateWebhook(hook); err != nil {
+		return hook, err
+	}
+	if hook.RealmID != nil {
+		if err := EnsureRealmWebhookPerm(context.Background(), *hook.RealmID, user); err != nil {
+			return hook, err
+		}
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return hook, fmt.Errorf("unable to generate webhook secret: %v", err)
+	}
+
+	hook.Secret = secret
+	hook.AccountID = user.ID
+	err = database.C.Save(&hook).Error
+
+	return hook, err
+}
+
+func EditWebhook(hook models.Webhook) (models.Webhook, error) {
+	if err := validateWebhook(hook); err != nil {
+		return hook, err
+	}
+
+	err := database.C.Omit("Realm").Save(&hook).Error
+
+	return hook, err
+}
+
+func RotateWebhookSecret(hook models.Webhook) (models.Webhook, error) {
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return hook, fmt.Errorf("unable to generate webhook secret: %v", err)
+	}
+
+	hook.Secret = secret
+	err = database.C.Model(&hook).Update("secret", secret).Error
+
+	return hook, err
+}
+
+func DeleteWebhook(hook models.Webhook) error {
+	return database.C.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", hook.ID).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&hook).Error
+	})
+}
+
+func CountWebhookDeliveries(hook models.Webhook) (int64, error) {
+	var count int64
+	err := database.C.Model(&models.WebhookDelivery{}).Where("webhook_id = ?", hook.ID).Count(&count).Error
+	return count, err
+}
+
+func ListWebhookDeliveries(hook models.Webhook, take, offset int) ([]models.WebhookDelivery, error) {
+	if take > 100 {
+		take = 100
+	}
+
+	var deliveries []models.WebhookDelivery
+	if err := database.C.
+		Where("webhook_id = ?", hook.ID).
+		Limit(take).Offset(offset).
+		Order("created_at DESC").
+		Find(&deliveries).Error; err != nil {
+		return deliveries, err
+	}
+
+	return deliveries, nil
+}
+
+func GetWebhookDelivery(hook models.Webhook, id uint) (models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := database.C.
+		Where("id = ? AND webhook_id = ?", id, hook.ID).
+		First(&delivery).Error; err != nil {
+		return delivery, err
+	}
+	return delivery, nil
+}
+
+// RedeliverWebhook sends the payload of a past delivery again as a new delivery,
+// the original one is kept in the log.
+func RedeliverWebhook(hook models.Webhook, delivery models.WebhookDelivery) (models.WebhookDelivery, error) {
+	redelivery := models.WebhookDelivery{
+		Event:         delivery.Event,
+		Payload:       delivery.Payload,
+		Status:        models.WebhookDeliveryPending,
+		NextAttemptAt: lo.ToPtr(time.Now()),
+		RedeliveryOf:  &delivery.ID,
+		WebhookID:     hook.ID,
+	}
+	if err := database.C.Save(&redelivery).Error; err != nil {
+		return redelivery, err
+	}
+
+	go sendWebhookDelivery(redelivery.ID)
+
+	return redelivery, nil
+}
+
+// EmitPostWebhookEvent queues the event for the webhooks of the post author and the post realm,
+// the realm webhooks only receive the events of the public posts.
+func EmitPostWebhookEvent(event string, post models.Post, data any) {
+	tx := database.C.Where("is_active = ? AND events @> ?::jsonb", true, fmt.Sprintf("[%q]", event))
+
+	// The scheduled posts are not public yet until they were published
+	isPublished := post.PublishedAt == nil || !post.PublishedAt.After(time.Now())
+	if !post.IsDraft && isPublished && post.Visibility == models.PostVisibilityAll && post.RealmID != nil {
+		tx = tx.Where("(realm_id IS NULL AND account_id = ?) OR realm_id = ?", post.AuthorID, *post.RealmID)
+	} else {
+		tx = tx.Where("realm_id IS NULL AND account_id = ?", post.AuthorID)
+	}
+
+	var hooks []models.Webhook
+	if err := tx.Find(&hooks).Error; err != nil {
+		log.Error().Err(err).Str("event", event).Msg("Unable to find webhooks for event...")
+		return
+	} else if len(hooks) == 0 {
+		return
+	}
+
+	payload, err := jsoniter.Marshal(WebhookPayload{
+		Event:     event,
+		CreatedAt: time.Now(),
+		Data:      data,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("event", event).Msg("Unable to encode webhook payload...")
+		return
+	}
+
+	deliveries := lo.Map(hooks, func(item models.Webhook, index int) models.WebhookDelivery {
+		return models.WebhookDelivery{
+			Event:         event,
+			Payload:       payload,
+			Status:        models.WebhookDeliveryPending,
+			NextAttemptAt: lo.ToPtr(time.Now()),
+			WebhookID:     item.ID,
+		}
+	})
+	if err := database.C.Create(&deliveries).Error; err != nil {
+		log.Error().Err(err).Str("event", event).Msg("Unable to queue webhook deliveries...")
+		return
+	}
+
+	sendWebhookDeliveries(lo.Map(deliveries, func(item models.WebhookDelivery, index int) uint {
+		return item.ID
+	}))
+}
+
+// SignWebhookPayload signs the payload with the webhook secret, the receivers verify
+// the signature by computing the hmac of the timestamp and the body joined by a dot.
+func SignWebhookPayload(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func postWebhook(hook models.Webhook, delivery models.WebhookDelivery) (int, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Hydrogen.Interactive Webhook")
+	req.Header.Set("X-Webhook-Event", delivery.Event)
+	req.Header.Set("X-Webhook-Delivery", strconv.Itoa(int(delivery.ID)))
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Webhook-Signature", SignWebhookPayload(hook.Secret, timestamp, delivery.Payload))
+
+	resp, err := getWebhookClient().Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, string(body), fmt.Errorf("endpoint responded %d", resp.StatusCode)
+	}
+	return resp.StatusCode, string(body), nil
+}
+
+func sendWebhookDelivery(id uint) {
+	if !webhookRetry.Claim(&models.WebhookDelivery{}, id, models.WebhookDeliveryPending) {
+		return
+	}
+
+	var delivery models.WebhookDelivery
+	if err := database.C.Where("id = ?", id).First(&delivery).Error; err != nil {
+		log.Error().Err(err).Uint("delivery", id).Msg("Unable to get webhook delivery...")
+		return
+	}
+
+	var hook models.Webhook
+	err := database.C.Where("id = ? AND is_active = ?", delivery.WebhookID, true).First(&hook).Error
+	if err != nil {
+		delivery.Error = "webhook was deleted or disabled"
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.NextAttemptAt = nil
+		database.C.Save(&delivery)
+		return
+	}
+
+	delivery.Attempts++
+	delivery.ResponseCode, delivery.ResponseBody, err = postWebhook(hook, delivery)
+	if err == nil {
+		delivery.Error = ""
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.DeliveredAt = lo.ToPtr(time.Now())
+		delivery.NextAttemptAt = nil
+	} else {
+		delivery.Error = err.Error()
+		if delivery.Attempts >= webhookRetry.MaxAttempts() {
+			delivery.Status = models.WebhookDeliveryFailed
+			delivery.NextAttemptAt = nil
+		} else {
+			delivery.NextAttemptAt = lo.ToPtr(time.Now().Add(webhookRetry.Backoff(delivery.Attempts)))
+		}
+		log.Warn().Err(err).Uint("delivery", delivery.ID).Int("attempts", delivery.Attempts).Msg("Unable to deliver webhook...")
+	}
+
+	if err := database.C.Save(&delivery).Error; err != nil {
+		log.Error().Err(err).Uint("delivery", delivery.ID).Msg("Unable to save webhook delivery...")
+	}
+}
+
+// sendWebhookDeliveries sends the deliveries concurrently and waits until all of them are done.
+func sendWebhookDeliveries(id []uint) {
+	var wg sync.WaitGroup
+	for _, item := range id {
+		wg.Add(1)
+		webhookSenders <- struct{}{}
+		go func(id uint) {
+			defer wg.Done()
+			defer func() { <-webhookSenders }()
+			sendWebhookDelivery(id)
+		}(item)
+	}
+	wg.Wait()
+}
+
+func DoWebhookRetry() {
+	var id []uint
+	if err := database.C.Model(&models.WebhookDelivery{}).
+		Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, time.Now()).
+		Order("next_attempt_at ASC").
+		Limit(100).
+		Pluck("id", &id).Error; err != nil {
+		log.Error().Err(err).Msg("An error occurred when retrying webhook deliveries...")
+		return
+	}
+
+	sendWebhookDeliveries(id)
+
+	if len(id) > 0 {
+		log.Debug().Int("count", len(id)).Msg("Retried pending webhook deliveries.")
+	}
+}
@@ -0,0 +1,85 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const gormSpanKey = "tracing:span"
+
+// GormPlugin starts a span for every query, the span is the child of the context
+// passed by WithContext, or a new trace when there isn't one.
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string {
+	return "tracing"
+}
+
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	before := func(operation string) func(*gorm.DB) {
+		return func(tx *gorm.DB) {
+			_, span := Tracer().Start(
+				tx.Statement.Context,
+				"gorm."+operation,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(semconv.DBSystemPostgreSQL),
+			)
+			tx.InstanceSet(gormSpanKey, span)
+		}
+	}
+	after := func(tx *gorm.DB) {
+		val, ok := tx.InstanceGet(gormSpanKey)
+		if !ok {
+			return
+		}
+		span := val.(trace.Span)
+		defer span.End()
+
+		span.SetAttributes(
+			semconv.DBCollectionName(tx.Statement.Table),
+			semconv.DBQueryText(tx.Statement.SQL.String()),
+		)
+		if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+			span.RecordError(tx.Error)
+			span.SetStatus(codes.Error, tx.Error.Error())
+		}
+	}
+
+	callbacks := db.Callback()
+	if err := callbacks.Create().Before("*").Register("tracing:before_create", before("create")); err != nil {
+		return err
+	} else if err := callbacks.Create().After("*").Register("tracing:after_create", after); err != nil {
+		return err
+	}
+	if err := callbacks.Query().Before("*").Register("tracing:before_query", before("query")); err != nil {
+		return err
+	} else if err := callbacks.Query().After("*").Register("tracing:after_query", after); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("*").Register("tracing:before_update", before("update")); err != nil {
+		return err
+	} else if err := callbacks.Update().After("*").Register("tracing:after_update", after); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("*").Register("tracing:before_delete", before("delete")); err != nil {
+		return err
+	} else if err := callbacks.Delete().After("*").Register("tracing:after_delete", after); err != nil {
+		return err
+	}
+	if err := callbacks.Row().Before("*").Register("tracing:before_row", before("row")); err != nil {
+		return err
+	} else if err := callbacks.Row().After("*").Register("tracing:after_row", after); err != nil {
+		return err
+	}
+	if err := callbacks.Raw().Before("*").Register("tracing:before_raw", before("raw")); err != nil {
+		return err
+	} else if err := callbacks.Raw().After("*").Register("tracing:after_raw", after); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type metadataCarrier metadata.MD
+
+func (v metadataCarrier) Get(key string) string {
+	if values := metadata.MD(v).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (v metadataCarrier) Set(key, value string) {
+	metadata.MD(v).Set(key, value)
+}
+
+func (v metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(v))
+	for key := range v {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// StartGrpcSpan starts a span for a grpc call and puts the trace into the outgoing metadata,
+// call the returned function with the result of the call.
+func StartGrpcSpan(ctx context.Context, service, method string) (context.Context, func(error)) {
+	ctx, span := Tracer().Start(
+		ctx,
+		service+"/"+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.RPCSystemGRPC,
+			semconv.RPCService(service),
+			semconv.RPCMethod(method),
+		),
+	)
+
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	otel.GetTextMapPropagator().Inject(ctx, metadataCarrier(md))
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	return ctx, func(err error) {
+		defer span.End()
+		code := status.Code(err)
+		span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(code)))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, code.String())
+		}
+	}
+}
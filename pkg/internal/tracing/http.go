@@ -0,0 +1,73 @@
+package tracing
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type headerCarrier struct {
+	c *fiber.Ctx
+}
+
+func (v headerCarrier) Get(key string) string {
+	return v.c.Get(key)
+}
+
+func (v headerCarrier) Set(key, value string) {
+	v.c.Request().Header.Set(key, value)
+}
+
+func (v headerCarrier) Keys() []string {
+	var keys []string
+	v.c.Request().Header.VisitAll(func(key, value []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
+}
+
+// HttpMiddleware starts a span for every request and puts it into the user context,
+// pass c.UserContext() to the database and the services to make their spans the children.
+func HttpMiddleware(c *fiber.Ctx) error {
+	ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), headerCarrier{c})
+	ctx, span := Tracer().Start(
+		ctx,
+		c.Method()+" "+c.Path(),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(c.Method()),
+			semconv.URLPath(c.Path()),
+			semconv.ClientAddress(c.IP()),
+			semconv.UserAgentOriginal(c.Get(fiber.HeaderUserAgent)),
+		),
+	)
+	defer span.End()
+
+	c.SetUserContext(ctx)
+	err := c.Next()
+
+	code := c.Response().StatusCode()
+	if err != nil {
+		var fe *fiber.Error
+		if errors.As(err, &fe) {
+			code = fe.Code
+		} else {
+			code = fiber.StatusInternalServerError
+		}
+		span.RecordError(err)
+	}
+
+	// The route is only known after the router matched it
+	route := c.Route().Path
+	span.SetName(c.Method() + " " + route)
+	span.SetAttributes(semconv.HTTPRoute(route), semconv.HTTPResponseStatusCode(code))
+	if code >= fiber.StatusInternalServerError {
+		span.SetStatus(codes.Error, fiber.ErrInternalServerError.Message)
+	}
+
+	return err
+}
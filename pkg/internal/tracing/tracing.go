@@ -0,0 +1,88 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	pkg "git.solsynth.dev/hydrogen/interactive/pkg/internal"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "git.solsynth.dev/hydrogen/interactive"
+
+var provider *sdktrace.TracerProvider
+
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// NewProvider sets up the exporter configured by the tracing.exporter setting,
+// the spans are dropped when it is none.
+func NewProvider() error {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if !IsEnabled() {
+		return nil
+	}
+
+	var err error
+	var exporter sdktrace.SpanExporter
+	switch viper.GetString("tracing.exporter") {
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case "otlp":
+		options := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(viper.GetString("tracing.endpoint"))}
+		if viper.GetBool("tracing.insecure") {
+			options = append(options, otlptracegrpc.WithInsecure())
+		}
+		exporter, err = otlptracegrpc.New(context.Background(), options...)
+	default:
+		return fmt.Errorf("unknown tracing exporter %s", viper.GetString("tracing.exporter"))
+	}
+	if err != nil {
+		return fmt.Errorf("unable to create tracing exporter: %v", err)
+	}
+
+	ratio := 1.0
+	if viper.IsSet("tracing.sample_ratio") {
+		ratio = viper.GetFloat64("tracing.sample_ratio")
+	}
+
+	provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName("interactive"),
+			semconv.ServiceVersion(pkg.AppVersion),
+			semconv.ServiceInstanceID(viper.GetString("id")),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+
+	return nil
+}
+
+func IsEnabled() bool {
+	exporter := viper.GetString("tracing.exporter")
+	return len(exporter) > 0 && exporter != "none"
+}
+
+// Shutdown flushes the spans still in the batch.
+func Shutdown(ctx context.Context) error {
+	if provider == nil {
+		return nil
+	}
+	return provider.Shutdown(ctx)
+}
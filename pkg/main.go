@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	pkg "git.solsynth.dev/hydrogen/interactive/pkg/internal"
 	"git.solsynth.dev/hydrogen/interactive/pkg/internal/database"
@@ -11,6 +13,7 @@ import (
 	"git.solsynth.dev/hydrogen/interactive/pkg/internal/grpc"
 	"git.solsynth.dev/hydrogen/interactive/pkg/internal/server"
 	"git.solsynth.dev/hydrogen/interactive/pkg/internal/services"
+	"git.solsynth.dev/hydrogen/interactive/pkg/internal/tracing"
 	"github.com/robfig/cron/v3"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -34,6 +37,11 @@ func main() {
 		log.Panic().Err(err).Msg("An error occurred when loading settings.")
 	}
 
+	// Configure tracing
+	if err := tracing.NewProvider(); err != nil {
+		log.Fatal().Err(err).Msg("An error occurred when initializing tracing.")
+	}
+
 	// Connect to database
 	if err := database.NewSource(); err != nil {
 		log.Fatal().Err(err).Msg("An error occurred when connect to database.")
@@ -46,15 +54,38 @@ func main() {
 		log.Fatal().Err(err).Msg("An error occurred when connecting to consul...")
 	}
 
+	// Configure language detection
+	if err := services.InitLanguageDetector(); err != nil {
+		log.Fatal().Err(err).Msg("An error occurred when initializing language detector.")
+	}
+
+	// Configure link previews
+	if viper.GetString("link_preview.fetcher") == "stub" {
+		services.SetLinkPreviewFetcher(services.StubLinkPreviewFetcher{})
+	}
+
 	// Configure timed tasks
 	quartz := cron.New(cron.WithLogger(cron.VerbosePrintfLogger(&log.Logger)))
 	quartz.AddFunc("@every 60m", services.DoAutoDatabaseCleanup)
+	quartz.AddFunc("@every 1m", services.DoAutoPollClose)
+	quartz.AddFunc("@every 10m", services.DoCacheMaintenance)
+	quartz.AddFunc("@every 1m", services.DoWebhookRetry)
+	quartz.AddFunc("@every 1m", services.FlushPostViews)
+	quartz.AddFunc("@every 30s", services.DoNotificationDispatch)
+	quartz.AddFunc("@every 60m", services.DoNotificationOutboxCleanup)
+	quartz.AddFunc("@every 5m", services.DoPostRenderBackfill)
+	quartz.AddFunc("@every 1m", services.DoScheduledPostFederation)
 	quartz.Start()
 
 	// Server
 	server.NewServer()
 	go server.Listen()
 
+	if viper.GetBool("metrics.enabled") {
+		server.NewMetricsServer()
+		go server.ListenMetrics()
+	}
+
 	grpc.NewGRPC()
 	go grpc.ListenGRPC()
 
@@ -68,4 +99,13 @@ func main() {
 	log.Info().Msgf("Interactive v%s is quitting...", pkg.AppVersion)
 
 	quartz.Stop()
+
+	// Write the views still in the buffer
+	services.FlushPostViews()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := tracing.Shutdown(ctx); err != nil {
+		log.Error().Err(err).Msg("An error occurred when flushing traces...")
+	}
 }